@@ -0,0 +1,484 @@
+// Package config loads the configuration of the server.
+//
+// Every setting has a key in the optional YAML or JSON configuration file
+// (such as db.host), a command-line flag (-db-host) and an environment
+// variable (DB_HOST). [Load] applies them in increasing order of precedence:
+// the defaults, the file, the environment, and then the flags. The file is
+// named by the -config flag or the CONFIG_FILE variable.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the server.
+type Config struct {
+	DB      DB
+	Server  Server
+	TLS     TLS
+	Paging  Paging
+	Auth    Auth
+	Limits  Limits
+	Tracing Tracing
+	// LogLevel is the minimum level of the logs: debug, info, warn or error.
+	// At debug, the SQL queries are logged.
+	LogLevel string
+}
+
+// DB configures the MySQL database and its connection pool.
+type DB struct {
+	// DSN is the data source name of the database. When set, it overrides
+	// the user, password, host, port and name.
+	DSN      string
+	User     string
+	Password string
+	Host     string
+	Port     int
+	Name     string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Server configures the listeners of the server.
+type Server struct {
+	// GRPCAddr, HTTPAddr and MetricsAddr are the listen addresses of the
+	// gRPC server, the REST/JSON gateway and the Prometheus metrics.
+	GRPCAddr    string
+	HTTPAddr    string
+	MetricsAddr string
+	// ShutdownTimeout is how long the in-flight RPCs are drained on shutdown
+	// before being cancelled.
+	ShutdownTimeout time.Duration
+}
+
+// TLS configures the certificate of the gRPC server. TLS is disabled when no
+// certificate is set. The files are reloaded when they change.
+type TLS struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the PEM file of the CAs verifying the client
+	// certificates, which then authenticate the clients. Clients without a
+	// certificate, such as the REST gateway, use the other credentials, so it
+	// requires the API keys or the JWKS file.
+	ClientCAFile string
+}
+
+// Enabled reports whether TLS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Paging configures the page sizes of the list and stream RPCs. Requests
+// without a size get the default, and larger sizes are reduced to the
+// maximum.
+type Paging struct {
+	DefaultPageSize        int
+	MaxPageSize            int
+	DefaultStreamBatchSize int
+	MaxStreamBatchSize     int
+}
+
+// PageSize returns the size of a page of the requested size.
+func (p Paging) PageSize(size int) int {
+	return clamp(size, p.DefaultPageSize, p.MaxPageSize)
+}
+
+// StreamBatchSize returns the size of a stream batch of the requested size.
+func (p Paging) StreamBatchSize(size int) int {
+	return clamp(size, p.DefaultStreamBatchSize, p.MaxStreamBatchSize)
+}
+
+// clamp returns def when size is not positive, or size capped to max.
+func clamp(size, def, max int) int {
+	switch {
+	case size <= 0:
+		return def
+	case size > max:
+		return max
+	}
+	return size
+}
+
+// Auth configures the authentication of the callers. It is disabled when
+// neither the API keys nor the JWKS file is set.
+type Auth struct {
+	APIKeysFile string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+// Limits configures the rate and time limits of the calls.
+type Limits struct {
+	// MaxInFlightListQueries caps the concurrent list queries of a client, or
+	// is 0 for no cap.
+	MaxInFlightListQueries int
+	// QueryTimeout bounds the queries of a call, or is 0 for no bound. The
+	// deadline of the call applies when it is sooner.
+	QueryTimeout time.Duration
+}
+
+// Tracing configures the export of the traces.
+type Tracing struct {
+	// Exporter is otlp, stdout or none.
+	Exporter     string
+	OTLPEndpoint string
+}
+
+// Default returns the default configuration, matching docker-compose.yml.
+func Default() *Config {
+	return &Config{
+		DB: DB{
+			User:            "root",
+			Password:        "example",
+			Host:            "db",
+			Port:            3306,
+			Name:            "platform",
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Server: Server{
+			GRPCAddr:        ":50051",
+			HTTPAddr:        ":8080",
+			MetricsAddr:     ":9092",
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Paging: Paging{
+			DefaultPageSize:        20,
+			MaxPageSize:            1000,
+			DefaultStreamBatchSize: 100,
+			MaxStreamBatchSize:     1000,
+		},
+		Limits:   Limits{MaxInFlightListQueries: 4, QueryTimeout: 10 * time.Second},
+		Tracing:  Tracing{Exporter: "none", OTLPEndpoint: "localhost:4317"},
+		LogLevel: "info",
+	}
+}
+
+// setting is a setting of the configuration.
+type setting struct {
+	// key is the key of the setting in the file; the flag is derived from it.
+	key string
+	// env is the environment variable of the setting.
+	env    string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+// flagName returns the name of the flag of s.
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// settings returns the settings of c, bound to its fields.
+func (c *Config) settings() []setting {
+	return []setting{
+		{"db.dsn", "DB_DSN", "data source name of the database, overriding the other db settings", true, (*stringValue)(&c.DB.DSN)},
+		{"db.user", "DB_USER", "database user", false, (*stringValue)(&c.DB.User)},
+		{"db.password", "DB_PASSWORD", "database password", true, (*stringValue)(&c.DB.Password)},
+		{"db.host", "DB_HOST", "database host", false, (*stringValue)(&c.DB.Host)},
+		{"db.port", "DB_PORT", "database port", false, (*intValue)(&c.DB.Port)},
+		{"db.name", "DB_NAME", "database name", false, (*stringValue)(&c.DB.Name)},
+		{"db.max_open_conns", "DB_MAX_OPEN_CONNS", "maximum open connections of the pool, or 0 for no limit", false, (*intValue)(&c.DB.MaxOpenConns)},
+		{"db.max_idle_conns", "DB_MAX_IDLE_CONNS", "maximum idle connections of the pool", false, (*intValue)(&c.DB.MaxIdleConns)},
+		{"db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "maximum lifetime of the connections, or 0 for no limit", false, (*durationValue)(&c.DB.ConnMaxLifetime)},
+		{"server.grpc_addr", "GRPC_ADDR", "listen address of the gRPC server", false, (*stringValue)(&c.Server.GRPCAddr)},
+		{"server.http_addr", "HTTP_ADDR", "listen address of the REST/JSON gateway", false, (*stringValue)(&c.Server.HTTPAddr)},
+		{"server.metrics_addr", "METRICS_ADDR", "listen address of the Prometheus metrics", false, (*stringValue)(&c.Server.MetricsAddr)},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "time to drain the in-flight RPCs on shutdown", false, (*durationValue)(&c.Server.ShutdownTimeout)},
+		{"tls.cert_file", "TLS_CERT_FILE", "PEM certificate of the gRPC server, enabling TLS", false, (*stringValue)(&c.TLS.CertFile)},
+		{"tls.key_file", "TLS_KEY_FILE", "PEM private key of the certificate", false, (*stringValue)(&c.TLS.KeyFile)},
+		{"tls.client_ca_file", "TLS_CLIENT_CA_FILE", "PEM CAs of the client certificates, enabling mutual TLS", false, (*stringValue)(&c.TLS.ClientCAFile)},
+		{"paging.default_page_size", "DEFAULT_PAGE_SIZE", "page size of the list requests without one", false, (*intValue)(&c.Paging.DefaultPageSize)},
+		{"paging.max_page_size", "MAX_PAGE_SIZE", "maximum page size of the list requests", false, (*intValue)(&c.Paging.MaxPageSize)},
+		{"paging.default_stream_batch_size", "DEFAULT_STREAM_BATCH_SIZE", "batch size of the stream requests without one", false, (*intValue)(&c.Paging.DefaultStreamBatchSize)},
+		{"paging.max_stream_batch_size", "MAX_STREAM_BATCH_SIZE", "maximum batch size of the stream requests", false, (*intValue)(&c.Paging.MaxStreamBatchSize)},
+		{"auth.api_keys_file", "AUTH_API_KEYS_FILE", "JSON file of the API keys", false, (*stringValue)(&c.Auth.APIKeysFile)},
+		{"auth.jwks_file", "AUTH_JWKS_FILE", "JWKS file of the keys signing the JWTs", false, (*stringValue)(&c.Auth.JWKSFile)},
+		{"auth.jwt_issuer", "AUTH_JWT_ISSUER", "expected issuer of the JWTs", false, (*stringValue)(&c.Auth.JWTIssuer)},
+		{"auth.jwt_audience", "AUTH_JWT_AUDIENCE", "expected audience of the JWTs", false, (*stringValue)(&c.Auth.JWTAudience)},
+		{"limits.max_in_flight_list_queries", "MAX_IN_FLIGHT_LIST_QUERIES", "maximum concurrent list queries per client, or 0 for no cap", false, (*intValue)(&c.Limits.MaxInFlightListQueries)},
+		{"limits.query_timeout", "QUERY_TIMEOUT", "maximum time of the queries of a call, or 0 for no limit", false, (*durationValue)(&c.Limits.QueryTimeout)},
+		{"tracing.exporter", "TRACES_EXPORTER", "trace exporter: otlp, stdout or none", false, (*stringValue)(&c.Tracing.Exporter)},
+		{"tracing.otlp_endpoint", "OTLP_ENDPOINT", "OTLP/gRPC endpoint of the trace collector", false, (*stringValue)(&c.Tracing.OTLPEndpoint)},
+		{"log_level", "LOG_LEVEL", "minimum log level: debug, info, warn or error", false, (*stringValue)(&c.LogLevel)},
+	}
+}
+
+// Load returns the configuration of the command-line arguments args (without
+// the program name) and the environment variables looked up by getenv, such
+// as os.LookupEnv.
+func Load(args []string, getenv func(string) (string, bool)) (*Config, error) {
+	c := Default()
+	settings := c.settings()
+
+	fs := flag.NewFlagSet("grpc_server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("config", "", "YAML or JSON configuration file (CONFIG_FILE)")
+	for _, s := range settings {
+		fs.String(s.flagName(), s.value.String(), fmt.Sprintf("%s (%s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return nil, err
+	}
+
+	// The file, then the environment, then the flags.
+	if *file == "" {
+		*file, _ = getenv("CONFIG_FILE")
+	}
+	if *file != "" {
+		if err := c.loadFile(*file, settings); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		if v, ok := getenv(s.env); ok {
+			if err := s.value.Set(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flagName() == f.Name && err == nil {
+				if serr := s.value.Set(f.Value.String()); serr != nil {
+					err = fmt.Errorf("-%s: %w", f.Name, serr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadFile sets the settings of the YAML or JSON file name.
+func (c *Config) loadFile(name string, settings []setting) error {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(buf, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, &doc)
+	default:
+		return fmt.Errorf("config file %s: unknown format: use .yaml, .yml or .json", name)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", name, err)
+	}
+	values := make(map[string]string)
+	if err := flatten("", doc, values); err != nil {
+		return fmt.Errorf("config file %s: %w", name, err)
+	}
+	for _, s := range settings {
+		if v, ok := values[s.key]; ok {
+			if err := s.value.Set(v); err != nil {
+				return fmt.Errorf("config file %s: %s: %w", name, s.key, err)
+			}
+			delete(values, s.key)
+		}
+	}
+	for key := range values {
+		return fmt.Errorf("config file %s: unknown setting %q", name, key)
+	}
+	return nil
+}
+
+// flatten stores the values of doc in values by their dotted keys.
+func flatten(prefix string, doc map[string]interface{}, values map[string]string) error {
+	for k, v := range doc {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(key+".", v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("setting %q: lists are not supported", key)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Validate checks the configuration, returning all of its problems.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, v ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, v...))
+		}
+	}
+
+	if c.DB.DSN != "" {
+		_, err := mysql.ParseDSN(c.DB.DSN)
+		check(err == nil, "db.dsn: %v", err)
+	} else {
+		check(c.DB.User != "", "db.user is required")
+		check(c.DB.Host != "", "db.host is required")
+		check(c.DB.Name != "", "db.name is required")
+		check(c.DB.Port > 0 && c.DB.Port < 65536, "db.port must be between 1 and 65535, got %d", c.DB.Port)
+	}
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must not exceed db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+
+	for key, addr := range map[string]string{
+		"server.grpc_addr":    c.Server.GRPCAddr,
+		"server.http_addr":    c.Server.HTTPAddr,
+		"server.metrics_addr": c.Server.MetricsAddr,
+	} {
+		_, port, err := net.SplitHostPort(addr)
+		check(err == nil && port != "", "%s: %q is not a host:port address", key, addr)
+	}
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file and tls.key_file must be set together")
+	check(c.TLS.ClientCAFile == "" || c.TLS.Enabled(), "tls.client_ca_file requires tls.cert_file")
+	check(c.TLS.ClientCAFile == "" || c.Auth.APIKeysFile != "" || c.Auth.JWKSFile != "",
+		"tls.client_ca_file requires auth.api_keys_file or auth.jwks_file: the REST gateway presents no client certificate, so its calls would all be unauthenticated")
+	for key, name := range map[string]string{"tls.cert_file": c.TLS.CertFile, "tls.key_file": c.TLS.KeyFile, "tls.client_ca_file": c.TLS.ClientCAFile, "auth.api_keys_file": c.Auth.APIKeysFile, "auth.jwks_file": c.Auth.JWKSFile} {
+		if name != "" {
+			_, err := os.Stat(name)
+			check(err == nil, "%s: %v", key, err)
+		}
+	}
+
+	check(c.Paging.DefaultPageSize > 0 && c.Paging.DefaultPageSize <= c.Paging.MaxPageSize,
+		"paging.default_page_size must be between 1 and paging.max_page_size (%d), got %d", c.Paging.MaxPageSize, c.Paging.DefaultPageSize)
+	check(c.Paging.DefaultStreamBatchSize > 0 && c.Paging.DefaultStreamBatchSize <= c.Paging.MaxStreamBatchSize,
+		"paging.default_stream_batch_size must be between 1 and paging.max_stream_batch_size (%d), got %d", c.Paging.MaxStreamBatchSize, c.Paging.DefaultStreamBatchSize)
+	check(c.Limits.MaxInFlightListQueries >= 0, "limits.max_in_flight_list_queries must not be negative")
+	check(c.Limits.QueryTimeout >= 0, "limits.query_timeout must not be negative")
+	check(slices.Contains([]string{"otlp", "stdout", "none"}, c.Tracing.Exporter), "tracing.exporter must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	_, err := c.Level()
+	check(err == nil, "log_level: %v", err)
+	return errors.Join(errs...)
+}
+
+// DataSourceName returns the DSN of the database. The models scan DATETIME
+// columns into time.Time, so the DSN always sets parseTime; a db.dsn that does
+// not parse, which Validate reports, is returned as is.
+func (c *Config) DataSourceName() string {
+	if c.DB.DSN != "" {
+		cfg, err := mysql.ParseDSN(c.DB.DSN)
+		if err != nil {
+			return c.DB.DSN
+		}
+		cfg.ParseTime = true
+		return cfg.FormatDSN()
+	}
+	cfg := mysql.NewConfig()
+	cfg.User = c.DB.User
+	cfg.Passwd = c.DB.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(c.DB.Host, strconv.Itoa(c.DB.Port))
+	cfg.DBName = c.DB.Name
+	cfg.ParseTime = true
+	return cfg.FormatDSN()
+}
+
+// Level returns the log level.
+func (c *Config) Level() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// redacted replaces the values of secrets.
+const redacted = "[REDACTED]"
+
+// String returns the effective configuration, one key=value setting per line,
+// with the secrets redacted.
+func (c *Config) String() string {
+	var sb strings.Builder
+	for _, s := range c.settings() {
+		v := s.value.String()
+		if s.secret && v != "" {
+			v = redacted
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", s.key, v)
+	}
+	return sb.String()
+}
+
+// LogValue satisfies the slog.LogValuer interface, logging the settings with
+// the secrets redacted.
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, s := range c.settings() {
+		v := s.value.String()
+		if s.secret && v != "" {
+			v = redacted
+		}
+		attrs = append(attrs, slog.String(s.key, v))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// stringValue is a flag.Value setting a string.
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+
+// intValue is a flag.Value setting an int.
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+// durationValue is a flag.Value setting a time.Duration.
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
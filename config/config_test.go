@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a lookup function of the variables of vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// TestLoadPrecedence tests that the environment overrides the file, and the
+// flags override the environment.
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte(`
+db:
+  host: file-host
+  port: 3307
+  user: file-user
+server:
+  shutdown_timeout: 10s
+paging:
+  max_page_size: 500
+log_level: debug
+`), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	c, err := Load(
+		[]string{"-config", file, "-db-host", "flag-host"},
+		env(map[string]string{"DB_HOST": "env-host", "DB_USER": "env-user", "DB_PASSWORD": "secret"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	tests := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{"Flag over env and file", c.DB.Host, "flag-host"},
+		{"Env over file", c.DB.User, "env-user"},
+		{"File over default", c.DB.Port, 3307},
+		{"File duration", c.Server.ShutdownTimeout, 10 * time.Second},
+		{"Nested file key", c.Paging.MaxPageSize, 500},
+		{"Default", c.DB.Name, "platform"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("Expected %v, got: %v", tt.expected, tt.got)
+			}
+		})
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected a valid config, got: %v", err)
+	}
+	if dsn := c.DataSourceName(); dsn != "env-user:secret@tcp(flag-host:3307)/platform?parseTime=true" {
+		t.Errorf("Unexpected DSN: %s", dsn)
+	}
+}
+
+// TestLoadErrors tests the invalid values and unknown settings of the
+// sources.
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(unknown, []byte(`{"db": {"hots": "db"}}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"Unknown file setting", []string{"-config", unknown}, nil},
+		{"Missing file", nil, map[string]string{"CONFIG_FILE": filepath.Join(dir, "missing.yaml")}},
+		{"Invalid env integer", nil, map[string]string{"DB_PORT": "mysql"}},
+		{"Invalid flag duration", []string{"-server-shutdown-timeout", "soon"}, nil},
+		{"Unknown flag", []string{"-db-hots", "db"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.args, env(tt.env)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+// TestValidate tests that every problem of a config is reported.
+func TestValidate(t *testing.T) {
+	c := Default()
+	c.DB.Port = 0
+	c.Server.GRPCAddr = "50051"
+	c.TLS.CertFile = "server.pem"
+	c.Paging.DefaultPageSize = 2000
+	c.LogLevel = "verbose"
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("Expected an invalid config")
+	}
+	for _, key := range []string{"db.port", "server.grpc_addr", "tls.cert_file", "paging.default_page_size", "log_level"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected a problem with %s, got: %v", key, err)
+		}
+	}
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Expected the default config to be valid, got: %v", err)
+	}
+
+	// Mutual TLS requires the certificate of the server.
+	c = Default()
+	c.TLS.ClientCAFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(c.TLS.ClientCAFile, nil, 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "tls.client_ca_file requires tls.cert_file") {
+		t.Errorf("Expected a problem with tls.client_ca_file, got: %v", err)
+	}
+
+	// The REST gateway has no client certificate, so mutual TLS requires
+	// other credentials.
+	c.TLS.CertFile, c.TLS.KeyFile = c.TLS.ClientCAFile, c.TLS.ClientCAFile
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "tls.client_ca_file requires auth.api_keys_file or auth.jwks_file") {
+		t.Errorf("Expected a problem with mutual TLS as the only authentication, got: %v", err)
+	}
+	c.Auth.APIKeysFile = c.TLS.ClientCAFile
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected mutual TLS with API keys to be valid, got: %v", err)
+	}
+}
+
+// TestDataSourceName tests that a configured DSN always parses times.
+func TestDataSourceName(t *testing.T) {
+	for _, tt := range []struct{ dsn, expected string }{
+		{"root:hunter2@tcp(db:3306)/platform", "root:hunter2@tcp(db:3306)/platform?parseTime=true"},
+		{"root:hunter2@tcp(db:3306)/platform?parseTime=false&timeout=5s", "root:hunter2@tcp(db:3306)/platform?parseTime=true&timeout=5s"},
+	} {
+		c := Default()
+		c.DB.DSN = tt.dsn
+		if got := c.DataSourceName(); got != tt.expected {
+			t.Errorf("Expected DSN %s for %s, got: %s", tt.expected, tt.dsn, got)
+		}
+	}
+}
+
+// TestString tests that the printed config redacts the secrets.
+func TestString(t *testing.T) {
+	c := Default()
+	c.DB.DSN = "root:hunter2@tcp(db:3306)/platform"
+	s := c.String()
+	if strings.Contains(s, "hunter2") || strings.Contains(s, "example") {
+		t.Errorf("Expected the secrets to be redacted, got:\n%s", s)
+	}
+	if !strings.Contains(s, "db.password="+redacted) || !strings.Contains(s, "db.host=db\n") {
+		t.Errorf("Expected the redacted password and the host, got:\n%s", s)
+	}
+}
+
+// TestPaging tests the defaults and maximums of the page sizes.
+func TestPaging(t *testing.T) {
+	p := Paging{DefaultPageSize: 20, MaxPageSize: 100, DefaultStreamBatchSize: 50, MaxStreamBatchSize: 500}
+	for _, tt := range []struct{ got, expected int }{
+		{p.PageSize(0), 20},
+		{p.PageSize(30), 30},
+		{p.PageSize(1000), 100},
+		{p.StreamBatchSize(-1), 50},
+		{p.StreamBatchSize(800), 500},
+	} {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %d, got: %d", tt.expected, tt.got)
+		}
+	}
+}
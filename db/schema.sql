@@ -3,7 +3,10 @@ CREATE TABLE `resources` (
     `uuid` VARCHAR(100) NOT NULL UNIQUE,
     `name` VARCHAR(100) NOT NULL,
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP NOT NULL
+    `updated_at` TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6) NOT NULL,
+    `deleted_at` TIMESTAMP NULL DEFAULT NULL,
+    INDEX `resources_created_at_idx` (`created_at`),
+    INDEX `resources_name_idx` (`name`)
 ) ENGINE=InnoDB;
 
 
@@ -12,7 +15,18 @@ CREATE TABLE `animal_rankings` (
     `rank`  INT NOT NULL unique,
     `name` VARCHAR(100) NOT NULL,
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP NOT NULL
+    `updated_at` TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6) NOT NULL,
+    INDEX `animal_rankings_name_idx` (`name`)
+) ENGINE=InnoDB;
+
+
+CREATE TABLE `resource_notes` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `resource_id` INT NOT NULL,
+    `body` TEXT NOT NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    INDEX `resource_notes_resource_id_created_at_idx` (`resource_id`, `created_at`),
+    CONSTRAINT `resource_notes_resource_id_fkey` FOREIGN KEY (`resource_id`) REFERENCES `resources` (`id`)
 ) ENGINE=InnoDB;
 
 
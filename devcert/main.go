@@ -0,0 +1,129 @@
+// Command devcert generates the self-signed certificates of a local TLS setup:
+// a CA, the certificate of the gRPC server signed by it, and a client
+// certificate for mutual TLS.
+//
+// Run the server with them with:
+//
+//	go run ./devcert
+//	TLS_CERT_FILE=certs/server.pem TLS_KEY_FILE=certs/server-key.pem TLS_CLIENT_CA_FILE=certs/ca.pem go run ./grpc_server
+//
+// and call it with:
+//
+//	grpcurl -cacert certs/ca.pem -cert certs/client.pem -key certs/client-key.pem localhost:50051 list
+//
+// The common name of the client certificate authenticates the client, its
+// organizational units are its roles and its organization is its tenant.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "certs", "directory of the generated files")
+	hosts := flag.String("hosts", "localhost,grpc_server,127.0.0.1,::1", "comma separated host names and IP addresses of the server")
+	client := flag.String("client", "dev", "common name of the client certificate, its subject")
+	roles := flag.String("roles", "writer", "comma separated roles of the client certificate")
+	tenant := flag.String("tenant", "", "tenant of the client certificate")
+	validFor := flag.Duration("valid-for", 365*24*time.Hour, "validity of the certificates")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatalf("Failed to create %s: %v", *dir, err)
+	}
+	notAfter := time.Now().Add(*validFor)
+
+	ca := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "paginator dev CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caKey, err := generate(*dir, "ca", ca, nil, nil, notAfter)
+	if err != nil {
+		log.Fatalf("Failed to generate the CA: %v", err)
+	}
+
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "paginator dev server"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range strings.Split(*hosts, ",") {
+		if ip := net.ParseIP(h); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else if h != "" {
+			server.DNSNames = append(server.DNSNames, h)
+		}
+	}
+	if _, err := generate(*dir, "server", server, ca, caKey, notAfter); err != nil {
+		log.Fatalf("Failed to generate the server certificate: %v", err)
+	}
+
+	clientCert := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: *client, OrganizationalUnit: strings.Split(*roles, ",")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if *tenant != "" {
+		clientCert.Subject.Organization = []string{*tenant}
+	}
+	if _, err := generate(*dir, "client", clientCert, ca, caKey, notAfter); err != nil {
+		log.Fatalf("Failed to generate the client certificate: %v", err)
+	}
+	fmt.Printf("Wrote ca.pem, server.pem, client.pem and their keys to %s\n", *dir)
+}
+
+// generate writes the certificate of template, signed by parent and its key,
+// or self-signed when parent is nil, to name.pem and its new key to
+// name-key.pem in dir. It returns the key.
+func generate(dir, name string, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, notAfter time.Time) (*ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = notAfter
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", der, 0o644); err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, name+"-key.pem"), "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writePEM writes the PEM block of typ and der to the file name.
+func writePEM(name, typ string, der []byte, perm os.FileMode) error {
+	return os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), perm)
+}
@@ -5,10 +5,9 @@ package models
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"strings"
 	"time"
+
+	"backend/paginator"
 )
 
 // AnimalRanking represents a row from 'platform.animal_rankings'.
@@ -64,21 +63,89 @@ func (ar *AnimalRanking) Insert(ctx context.Context, db DB) error {
 	return nil
 }
 
+// InsertAnimalRankingBatch inserts the [AnimalRanking] rows to the database using multi-row INSERT
+// statements, split into chunks that fit within the driver's placeholder limit.
+// The primary keys generated by the database are set on the rows.
+//
+// The primary keys are computed from the id of the first row inserted by each statement,
+// which requires a statement to allocate consecutive ids: the server must not run with
+// innodb_autoinc_lock_mode = 2 (interleaved) and auto_increment_increment must be 1.
+//
+// Each chunk is a separate statement: use a transaction to insert the rows atomically.
+func InsertAnimalRankingBatch(ctx context.Context, db DB, ars []*AnimalRanking) error {
+	for _, ar := range ars {
+		switch {
+		case ar._exists: // already exists
+			return logerror(&ErrInsertFailed{ErrAlreadyExists})
+		case ar._deleted: // deleted
+			return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+		}
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO platform.animal_rankings (` +
+		`rank, name, created_at, updated_at` +
+		`) VALUES `
+	for len(ars) != 0 {
+		chunk := ars[:min(len(ars), batchRows(4))]
+		ars = ars[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*4)
+		for _, ar := range chunk {
+			args = append(args, ar.Rank, ar.Name, ar.CreatedAt, ar.UpdatedAt)
+		}
+		query := sqlstr + batchValues(len(chunk), 4)
+		// run
+		logf(query, args...)
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return logerror(err)
+		}
+		// retrieve id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return logerror(err)
+		}
+		// set primary keys, allocated consecutively for a multi-row insert
+		for i, ar := range chunk {
+			ar.ID = int(id + int64(i))
+		}
+		// set exists
+		for _, ar := range chunk {
+			ar._exists = true
+		}
+	}
+	return nil
+}
+
 // Update updates a [AnimalRanking] in the database.
 func (ar *AnimalRanking) Update(ctx context.Context, db DB) error {
 	switch {
 	case !ar._exists: // doesn't exist
 		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
-	case ar._deleted: // deleted
+	case ar.Deleted(): // deleted
 		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
 	}
-	// update with primary key
+	// update with primary key, the database sets updated_at
 	const sqlstr = `UPDATE platform.animal_rankings SET ` +
-		`rank = ?, name = ?, created_at = ?, updated_at = ? ` +
+		`rank = ?, name = ?, created_at = ? ` +
+		`WHERE id = ?`
+	// run
+	logf(sqlstr, ar.Rank, ar.Name, ar.CreatedAt, ar.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, ar.Rank, ar.Name, ar.CreatedAt, ar.ID); err != nil {
+		return logerror(err)
+	}
+	return ar.touch(ctx, db)
+}
+
+// touch reads the updated_at set by the database on the last change of the [AnimalRanking],
+// which reports the change to [AnimalRankingChangesSince].
+func (ar *AnimalRanking) touch(ctx context.Context, db DB) error {
+	// query
+	const sqlstr = `SELECT updated_at ` +
+		`FROM platform.animal_rankings ` +
 		`WHERE id = ?`
 	// run
-	logf(sqlstr, ar.Rank, ar.Name, ar.CreatedAt, ar.UpdatedAt, ar.ID)
-	if _, err := db.ExecContext(ctx, sqlstr, ar.Rank, ar.Name, ar.CreatedAt, ar.UpdatedAt, ar.ID); err != nil {
+	if err := selectRow(ctx, db, sqlstr, ar.ID).Scan(&ar.UpdatedAt); err != nil {
 		return logerror(err)
 	}
 	return nil
@@ -116,6 +183,45 @@ func (ar *AnimalRanking) Upsert(ctx context.Context, db DB) error {
 	return nil
 }
 
+// UpsertAnimalRankingBatch performs an upsert for the [AnimalRanking] rows using multi-row statements,
+// split into chunks that fit within the driver's placeholder limit.
+//
+// Each chunk is a separate statement: use a transaction to upsert the rows atomically.
+func UpsertAnimalRankingBatch(ctx context.Context, db DB, ars []*AnimalRanking) error {
+	for _, ar := range ars {
+		switch {
+		case ar._deleted: // deleted
+			return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+		}
+	}
+	// upsert
+	const sqlstr = `INSERT INTO platform.animal_rankings (` +
+		`id, rank, name, created_at, updated_at` +
+		`) VALUES `
+	const conflict = ` ON DUPLICATE KEY UPDATE ` +
+		`rank = VALUES(rank), name = VALUES(name), created_at = VALUES(created_at), updated_at = VALUES(updated_at)`
+	for len(ars) != 0 {
+		chunk := ars[:min(len(ars), batchRows(5))]
+		ars = ars[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*5)
+		for _, ar := range chunk {
+			args = append(args, ar.ID, ar.Rank, ar.Name, ar.CreatedAt, ar.UpdatedAt)
+		}
+		query := sqlstr + batchValues(len(chunk), 5) + conflict
+		// run
+		logf(query, args...)
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return logerror(err)
+		}
+		// set exists
+		for _, ar := range chunk {
+			ar._exists = true
+		}
+	}
+	return nil
+}
+
 // Delete deletes the [AnimalRanking] from the database.
 func (ar *AnimalRanking) Delete(ctx context.Context, db DB) error {
 	switch {
@@ -144,90 +250,114 @@ func (ar *AnimalRanking) Delete(ctx context.Context, db DB) error {
 //
 // If `order` is `ASC`, it retrieves records where the value of `column` is greater than `key`.
 // If `order` is `DESC`, it retrieves records where the value of `column` is less than `key`.
+// A nil `key` retrieves the first page.
+// Records with equal values of `column` are ordered by `id`: a `key` made by
+// [paginator.AfterRow] from the values of `column` and `id` of the last record
+// resumes after it without skipping the records equal to it.
 //
 // Filters are dynamically provided via a `filters` map, where keys are column names and values are either single values or slices for `IN` clauses.
-func AnimalRankingKeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}) ([]*AnimalRanking, *AnimalRanking, error) {
-	if order != "ASC" && order != "DESC" {
-		return nil, nil, fmt.Errorf("invalid order: %s", order)
+// Typed conditions such as time ranges are provided via `where`.
+// Invalid columns, orders, limits and filters are reported as a [paginator.FieldError].
+func AnimalRankingKeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]*AnimalRanking, *AnimalRanking, error) {
+	q := paginator.Query{
+		From:     "animal_rankings",
+		Columns:  []string{"id", "rank", "name", "created_at", "updated_at"},
+		Where:    where,
+		Sort:     paginator.SortKey{Column: column, Order: paginator.Order(order)},
+		Tiebreak: "id",
+		Filters:  filters,
+		Limit:    limit,
 	}
-
-	// Start building the query
-	query := fmt.Sprintf(
-		`SELECT * FROM animal_rankings 
-         WHERE %s %s ?`,
-		column, condition(order), // Ensure this is returning a valid operator
-	)
-
-	// Arguments for the query
-	args := []interface{}{key}
-
-	// Dynamically add filters from the `filters` map to the query
-	for field, value := range filters {
-		switch v := value.(type) {
-		case []int:
-			if len(v) > 0 {
-				placeholders := make([]string, len(v))
-				for i := range v {
-					placeholders[i] = "?"
-					args = append(args, v[i])
-				}
-				query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
-			}
-		case []string:
-			if len(v) > 0 {
-				placeholders := make([]string, len(v))
-				for i := range v {
-					placeholders[i] = "?"
-					args = append(args, v[i])
-				}
-				query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
+	page, err := paginator.Fetch(ctx, logQueryer{db}, dialect, q, paginator.After(key), paginator.Mapper[*AnimalRanking]{
+		Scan: func(s paginator.Scanner) (*AnimalRanking, error) {
+			ar := AnimalRanking{
+				_exists: true,
 			}
-		default:
-			query += fmt.Sprintf(" AND %s = ?", field)
-			args = append(args, value)
-		}
+			err := s.Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt)
+			return &ar, err
+		},
+	})
+	if err != nil {
+		return nil, nil, logerror(err)
 	}
+	// the last record is the key for the next page
+	last, _ := page.Last()
+	return page.Items, last, nil
+}
 
-	// Finalize the query with the order and limit
-	query += fmt.Sprintf(" ORDER BY %s %s LIMIT ?", column, order)
-	args = append(args, limit)
+// AnimalRankingCollection describes the 'platform.animal_rankings' table paged by [AnimalRankingKeysetPage].
+//
+// Generated from the columns and indexes of 'animal_rankings'.
+var AnimalRankingCollection = &Collection{
+	Name: "animal_rankings",
+	Fields: []CollectionField{
+		{Name: "id", Type: "int", GoType: "int", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "rank", Type: "int", GoType: "int", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "name", Type: "varchar", GoType: "string", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "created_at", Type: "timestamp", GoType: "time.Time", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "updated_at", Type: "timestamp", GoType: "time.Time", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+	},
+	SortKeys: []string{"id", "name", "rank"},
+}
 
-	// Log the final query for debugging purposes
-	log.Printf("Executing query: %s with args: %v", query, args)
+func init() {
+	registerCollection(AnimalRankingCollection)
+}
+
+// AnimalRankingWatermark is a position in the [AnimalRanking] change feed: the updated_at and
+// id of the last change read. The zero value starts from the first change.
+type AnimalRankingWatermark struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        int       `json:"id"`
+}
 
-	// Execute the query
-	rows, err := db.QueryContext(ctx, query, args...)
+// AnimalRankingChangesSince retrieves up to `limit` [AnimalRanking] records changed after the watermark,
+// ordered by (updated_at, id), with the watermark to resume from. The watermark
+// is returned unchanged when there are no new changes.
+//
+// The updated_at of a change is set by the database (ON UPDATE CURRENT_TIMESTAMP(6)) when
+// its statement runs, not when its transaction commits: a change committed after a later
+// change has been read falls behind the watermark and is skipped. Consumers that need every
+// change should resume from a watermark moved back by the longest transaction, and skip the
+// records read again.
+//
+// Deleted rows are not reported; add a nullable `deleted_at` column to the table to
+// report deletions as soft deleted records.
+func AnimalRankingChangesSince(ctx context.Context, db DB, watermark AnimalRankingWatermark, limit int) ([]*AnimalRanking, AnimalRankingWatermark, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`id, rank, name, created_at, updated_at ` +
+		`FROM platform.animal_rankings ` +
+		`WHERE (updated_at > ? OR (updated_at = ? AND id > ?)) ` +
+		`ORDER BY updated_at, id ` +
+		`LIMIT ?`
+	// run
+	rows, err := logQueryer{db}.QueryContext(ctx, sqlstr, watermark.UpdatedAt, watermark.UpdatedAt, watermark.ID, limit)
 	if err != nil {
-		return nil, nil, logerror(err)
+		return nil, watermark, logerror(err)
 	}
 	defer rows.Close()
-
-	var results []*AnimalRanking
-	var lastItem *AnimalRanking // Variable to store the last item
-
+	// process
+	var res []*AnimalRanking
 	for rows.Next() {
 		ar := AnimalRanking{
 			_exists: true,
 		}
-		if err := rows.Scan(
-			&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt,
-		); err != nil {
-			return nil, nil, logerror(err)
+		// scan
+		if err := rows.Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
+			return nil, watermark, logerror(err)
 		}
-		results = append(results, &ar)
+		res = append(res, &ar)
 	}
-
-	// Check for errors during row iteration.
 	if err := rows.Err(); err != nil {
-		return nil, nil, logerror(err)
+		return nil, watermark, logerror(err)
 	}
-
-	// If we have results, set the lastItem to the last element in results.
-	if len(results) > 0 {
-		lastItem = results[len(results)-1]
+	// resume after the last change
+	if len(res) > 0 {
+		last := res[len(res)-1]
+		watermark = AnimalRankingWatermark{UpdatedAt: last.UpdatedAt, ID: last.ID}
 	}
-
-	return results, lastItem, nil
+	return res, watermark, nil
 }
 
 // AnimalRankingByID retrieves a row from 'platform.animal_rankings' as a [AnimalRanking].
@@ -240,16 +370,48 @@ func AnimalRankingByID(ctx context.Context, db DB, id int) (*AnimalRanking, erro
 		`FROM platform.animal_rankings ` +
 		`WHERE id = ?`
 	// run
-	logf(sqlstr, id)
 	ar := AnimalRanking{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
+	if err := selectRow(ctx, db, sqlstr, id).Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &ar, nil
 }
 
+// AnimalRankingsByName retrieves a row from 'platform.animal_rankings' as a [AnimalRanking].
+//
+// Generated from index 'animal_rankings_name_idx'.
+func AnimalRankingsByName(ctx context.Context, db DB, name string) ([]*AnimalRanking, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`id, rank, name, created_at, updated_at ` +
+		`FROM platform.animal_rankings ` +
+		`WHERE name = ?`
+	// run
+	rows, err := selectRows(ctx, db, sqlstr, name)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*AnimalRanking
+	for rows.Next() {
+		ar := AnimalRanking{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &ar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // AnimalRankingByRank retrieves a row from 'platform.animal_rankings' as a [AnimalRanking].
 //
 // Generated from index 'rank'.
@@ -260,11 +422,10 @@ func AnimalRankingByRank(ctx context.Context, db DB, rank int) (*AnimalRanking,
 		`FROM platform.animal_rankings ` +
 		`WHERE rank = ?`
 	// run
-	logf(sqlstr, rank)
 	ar := AnimalRanking{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, rank).Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
+	if err := selectRow(ctx, db, sqlstr, rank).Scan(&ar.ID, &ar.Rank, &ar.Name, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &ar, nil
@@ -119,6 +119,18 @@ func initAnimalRankingsTestDB() (*sql.DB, error) {
 		return nil, err
 	}
 
+	// Set updated_at on changes as ON UPDATE CURRENT_TIMESTAMP(6) does
+	_, err = db.Exec(`
+		CREATE TRIGGER animal_rankings_updated_at AFTER UPDATE ON animal_rankings
+		WHEN NEW.updated_at IS OLD.updated_at
+		BEGIN
+			UPDATE animal_rankings SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+		END
+	`)
+	if err != nil {
+		return nil, err
+	}
+
 	// Insert sample data into the table using fixed times
 	sampleData := []struct {
 		Rank      int
@@ -7,6 +7,11 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/paginator"
 )
 
 var (
@@ -16,12 +21,227 @@ var (
 	errf = func(string, ...interface{}) {}
 )
 
-// condition returns the appropriate SQL comparison operator based on the `order` parameter.
-func condition(order string) string {
-	if order == "ASC" {
-		return ">"
+// dialect renders the keyset pages run by the paginator package.
+var dialect paginator.Dialect = paginator.MySQL
+
+// logQueryer runs the generated SELECT queries, and those of the paginator
+// package, on the wrapped [DB]. It logs them, and limits their execution time
+// to the deadline of the context when the dialect supports it.
+type logQueryer struct {
+	DB
+}
+
+// QueryContext satisfies the [paginator.Queryer] interface.
+func (q logQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query = q.limit(ctx, query, args)
+	return q.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a query returning at most one row.
+func (q logQueryer) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query = q.limit(ctx, query, args)
+	return q.DB.QueryRowContext(ctx, query, args...)
+}
+
+// limit returns the query limited to the deadline of ctx, and logs it.
+func (logQueryer) limit(ctx context.Context, query string, args []interface{}) string {
+	if deadline, ok := ctx.Deadline(); ok {
+		query = paginator.LimitExecutionTime(dialect, query, time.Until(deadline))
+	}
+	logf(query, args...)
+	return query
+}
+
+// selectRows runs the SELECT query on db through a [logQueryer].
+func selectRows(ctx context.Context, db DB, query string, args ...interface{}) (*sql.Rows, error) {
+	return logQueryer{db}.QueryContext(ctx, query, args...)
+}
+
+// selectRow runs the SELECT query, returning at most one row, on db through a
+// [logQueryer].
+func selectRow(ctx context.Context, db DB, query string, args ...interface{}) *sql.Row {
+	return logQueryer{db}.QueryRowContext(ctx, query, args...)
+}
+
+// DeletedScope selects which rows generated queries return for tables with a
+// soft delete (`deleted_at`) column.
+type DeletedScope int
+
+// DeletedScope values.
+const (
+	// ExcludeDeleted returns only rows that have not been soft deleted. This is
+	// the default when the context carries no scope.
+	ExcludeDeleted DeletedScope = iota
+	// IncludeDeleted returns rows whether or not they have been soft deleted.
+	IncludeDeleted
+	// OnlyDeleted returns only rows that have been soft deleted.
+	OnlyDeleted
+)
+
+// deletedScopeKey is the context key for the [DeletedScope].
+type deletedScopeKey struct{}
+
+// WithDeletedScope returns a copy of ctx that makes generated keyset pages and
+// index lookups return rows according to scope.
+func WithDeletedScope(ctx context.Context, scope DeletedScope) context.Context {
+	return context.WithValue(ctx, deletedScopeKey{}, scope)
+}
+
+// deletedClause returns the SQL predicate restricting the soft delete column
+// to the [DeletedScope] carried by ctx.
+func deletedClause(ctx context.Context, column string) string {
+	scope, _ := ctx.Value(deletedScopeKey{}).(DeletedScope)
+	switch scope {
+	case IncludeDeleted:
+		return ""
+	case OnlyDeleted:
+		return " AND " + column + " IS NOT NULL"
+	}
+	return " AND " + column + " IS NULL"
+}
+
+// deletedConditions returns the paginator conditions restricting the soft
+// delete column to the [DeletedScope] carried by ctx.
+func deletedConditions(ctx context.Context, column string) []paginator.Condition {
+	scope, _ := ctx.Value(deletedScopeKey{}).(DeletedScope)
+	switch scope {
+	case IncludeDeleted:
+		return nil
+	case OnlyDeleted:
+		return []paginator.Condition{paginator.IsNotNull(column)}
+	}
+	return []paginator.Condition{paginator.IsNull(column)}
+}
+
+// tenantKey is the context key for the tenant.
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx that scopes generated queries on tables with
+// a `tenant_id` column to tenant. The tenant must have the Go type of the
+// `tenant_id` field.
+func WithTenant(ctx context.Context, tenant interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, if any.
+func TenantFromContext(ctx context.Context) (interface{}, bool) {
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// tenantFrom returns the tenant carried by ctx, or [ErrNoTenant].
+func tenantFrom(ctx context.Context) (interface{}, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return tenant, nil
+}
+
+// scopeTenant scopes the tenant field of a row to the tenant carried by ctx,
+// setting it when it is the zero value. It fails when ctx carries no tenant
+// and when the row belongs to another tenant.
+func scopeTenant[T comparable](ctx context.Context, field *T) error {
+	tenant, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	v, ok := tenant.(T)
+	if !ok {
+		return fmt.Errorf("invalid tenant type %T, expected %T", tenant, *field)
+	}
+	var zero T
+	switch {
+	case *field == zero:
+		*field = v
+	case *field != v:
+		return ErrWrongTenant
+	}
+	return nil
+}
+
+// Collection describes a table paged by its generated KeysetPage function: its
+// fields, and the keys its pages can be sorted on without scanning the table.
+type Collection struct {
+	// Name is the name of the table.
+	Name   string
+	Fields []CollectionField
+	// SortKeys are the columns leading the indexes of the table.
+	SortKeys []string
+}
+
+// Field returns the field of the column name.
+func (c *Collection) Field(name string) (CollectionField, bool) {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return CollectionField{}, false
+}
+
+// CollectionField describes a column of a [Collection].
+type CollectionField struct {
+	Name string
+	// Type is the database type of the column, and GoType the type of its
+	// field.
+	Type     string
+	GoType   string
+	Nullable bool
+	// Operators are the operators of the [paginator.Condition] the column can
+	// be filtered with.
+	Operators []string
+}
+
+// collections are the registered collections, by name.
+var collections = make(map[string]*Collection)
+
+// registerCollection registers the collection of a generated table.
+func registerCollection(c *Collection) {
+	collections[c.Name] = c
+}
+
+// Collections returns the collections of the generated tables, ordered by
+// name.
+func Collections() []*Collection {
+	list := make([]*Collection, 0, len(collections))
+	for _, c := range collections {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// CollectionByName returns the collection of the table name.
+func CollectionByName(name string) (*Collection, bool) {
+	c, ok := collections[name]
+	return c, ok
+}
+
+// maxPlaceholders is the maximum number of placeholders in a single statement,
+// used to split the rows of generated batch inserts and upserts into chunks.
+const maxPlaceholders = 65535
+
+// batchRows returns the number of rows of cols columns each in a chunk of a
+// generated batch insert or upsert. Tests replace it to split small batches.
+var batchRows = func(cols int) int {
+	return maxPlaceholders / cols
+}
+
+// batchValues returns the VALUES list of a multi-row statement inserting rows
+// rows of cols columns each.
+func batchValues(rows, cols int) string {
+	list := make([]string, rows)
+	params := make([]string, cols)
+	for i := range list {
+		for j := range params {
+			params[j] = "?"
+		}
+		list[i] = "(" + strings.Join(params, ", ") + ")"
 	}
-	return "<"
+	return strings.Join(list, ", ")
 }
 
 // logerror logs the error and returns it.
@@ -101,6 +321,11 @@ const (
 	ErrDoesNotExist Error = "does not exist"
 	// ErrMarkedForDeletion is the marked for deletion error.
 	ErrMarkedForDeletion Error = "marked for deletion"
+	// ErrNoTenant is the missing tenant error, returned by queries on tenant
+	// scoped tables when the context carries no tenant.
+	ErrNoTenant Error = "no tenant in context"
+	// ErrWrongTenant is the wrong tenant error.
+	ErrWrongTenant Error = "belongs to another tenant"
 )
 
 // ErrInsertFailed is the insert failed error.
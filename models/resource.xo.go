@@ -4,19 +4,20 @@ package models
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"strings"
+	"database/sql"
 	"time"
+
+	"backend/paginator"
 )
 
 // Resource represents a row from 'platform.resources'.
 type Resource struct {
-	ID        int       `json:"id"`         // id
-	UUID      string    `json:"uuid"`       // uuid
-	Name      string    `json:"name"`       // name
-	CreatedAt time.Time `json:"created_at"` // created_at
-	UpdatedAt time.Time `json:"updated_at"` // updated_at
+	ID        int          `json:"id"`         // id
+	UUID      string       `json:"uuid"`       // uuid
+	Name      string       `json:"name"`       // name
+	CreatedAt time.Time    `json:"created_at"` // created_at
+	UpdatedAt time.Time    `json:"updated_at"` // updated_at
+	DeletedAt sql.NullTime `json:"deleted_at"` // deleted_at
 	// xo fields
 	_exists, _deleted bool
 }
@@ -29,7 +30,7 @@ func (r *Resource) Exists() bool {
 // Deleted returns true when the [Resource] has been marked for deletion
 // from the database.
 func (r *Resource) Deleted() bool {
-	return r._deleted
+	return r._deleted || r.DeletedAt.Valid
 }
 
 // Insert inserts the [Resource] to the database.
@@ -42,13 +43,13 @@ func (r *Resource) Insert(ctx context.Context, db DB) error {
 	}
 	// insert (primary key generated and returned by database)
 	const sqlstr = `INSERT INTO platform.resources (` +
-		`uuid, name, created_at, updated_at` +
+		`uuid, name, created_at, updated_at, deleted_at` +
 		`) VALUES (` +
-		`?, ?, ?, ?` +
+		`?, ?, ?, ?, ?` +
 		`)`
 	// run
-	logf(sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt)
-	res, err := db.ExecContext(ctx, sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt)
+	logf(sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt)
+	res, err := db.ExecContext(ctx, sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt)
 	if err != nil {
 		return logerror(err)
 	}
@@ -63,21 +64,89 @@ func (r *Resource) Insert(ctx context.Context, db DB) error {
 	return nil
 }
 
+// InsertResourceBatch inserts the [Resource] rows to the database using multi-row INSERT
+// statements, split into chunks that fit within the driver's placeholder limit.
+// The primary keys generated by the database are set on the rows.
+//
+// The primary keys are computed from the id of the first row inserted by each statement,
+// which requires a statement to allocate consecutive ids: the server must not run with
+// innodb_autoinc_lock_mode = 2 (interleaved) and auto_increment_increment must be 1.
+//
+// Each chunk is a separate statement: use a transaction to insert the rows atomically.
+func InsertResourceBatch(ctx context.Context, db DB, rs []*Resource) error {
+	for _, r := range rs {
+		switch {
+		case r._exists: // already exists
+			return logerror(&ErrInsertFailed{ErrAlreadyExists})
+		case r._deleted: // deleted
+			return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+		}
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO platform.resources (` +
+		`uuid, name, created_at, updated_at, deleted_at` +
+		`) VALUES `
+	for len(rs) != 0 {
+		chunk := rs[:min(len(rs), batchRows(5))]
+		rs = rs[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*5)
+		for _, r := range chunk {
+			args = append(args, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt)
+		}
+		query := sqlstr + batchValues(len(chunk), 5)
+		// run
+		logf(query, args...)
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return logerror(err)
+		}
+		// retrieve id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return logerror(err)
+		}
+		// set primary keys, allocated consecutively for a multi-row insert
+		for i, r := range chunk {
+			r.ID = int(id + int64(i))
+		}
+		// set exists
+		for _, r := range chunk {
+			r._exists = true
+		}
+	}
+	return nil
+}
+
 // Update updates a [Resource] in the database.
 func (r *Resource) Update(ctx context.Context, db DB) error {
 	switch {
 	case !r._exists: // doesn't exist
 		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
-	case r._deleted: // deleted
+	case r.Deleted(): // deleted
 		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
 	}
-	// update with primary key
+	// update with primary key, the database sets updated_at
 	const sqlstr = `UPDATE platform.resources SET ` +
-		`uuid = ?, name = ?, created_at = ?, updated_at = ? ` +
+		`uuid = ?, name = ?, created_at = ?, deleted_at = ? ` +
+		`WHERE id = ?`
+	// run
+	logf(sqlstr, r.UUID, r.Name, r.CreatedAt, r.DeletedAt, r.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, r.UUID, r.Name, r.CreatedAt, r.DeletedAt, r.ID); err != nil {
+		return logerror(err)
+	}
+	return r.touch(ctx, db)
+}
+
+// touch reads the updated_at set by the database on the last change of the [Resource],
+// which reports the change to [ResourceChangesSince].
+func (r *Resource) touch(ctx context.Context, db DB) error {
+	// query
+	const sqlstr = `SELECT updated_at ` +
+		`FROM platform.resources ` +
 		`WHERE id = ?`
 	// run
-	logf(sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.ID)
-	if _, err := db.ExecContext(ctx, sqlstr, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.ID); err != nil {
+	if err := selectRow(ctx, db, sqlstr, r.ID).Scan(&r.UpdatedAt); err != nil {
 		return logerror(err)
 	}
 	return nil
@@ -99,15 +168,15 @@ func (r *Resource) Upsert(ctx context.Context, db DB) error {
 	}
 	// upsert
 	const sqlstr = `INSERT INTO platform.resources (` +
-		`id, uuid, name, created_at, updated_at` +
+		`id, uuid, name, created_at, updated_at, deleted_at` +
 		`) VALUES (` +
-		`?, ?, ?, ?, ?` +
+		`?, ?, ?, ?, ?, ?` +
 		`)` +
 		` ON DUPLICATE KEY UPDATE ` +
-		`uuid = VALUES(uuid), name = VALUES(name), created_at = VALUES(created_at), updated_at = VALUES(updated_at)`
+		`uuid = VALUES(uuid), name = VALUES(name), created_at = VALUES(created_at), updated_at = VALUES(updated_at), deleted_at = VALUES(deleted_at)`
 	// run
-	logf(sqlstr, r.ID, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt)
-	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt); err != nil {
+	logf(sqlstr, r.ID, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt)
+	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -115,8 +184,97 @@ func (r *Resource) Upsert(ctx context.Context, db DB) error {
 	return nil
 }
 
-// Delete deletes the [Resource] from the database.
+// UpsertResourceBatch performs an upsert for the [Resource] rows using multi-row statements,
+// split into chunks that fit within the driver's placeholder limit.
+//
+// Each chunk is a separate statement: use a transaction to upsert the rows atomically.
+func UpsertResourceBatch(ctx context.Context, db DB, rs []*Resource) error {
+	for _, r := range rs {
+		switch {
+		case r._deleted: // deleted
+			return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+		}
+	}
+	// upsert
+	const sqlstr = `INSERT INTO platform.resources (` +
+		`id, uuid, name, created_at, updated_at, deleted_at` +
+		`) VALUES `
+	const conflict = ` ON DUPLICATE KEY UPDATE ` +
+		`uuid = VALUES(uuid), name = VALUES(name), created_at = VALUES(created_at), updated_at = VALUES(updated_at), deleted_at = VALUES(deleted_at)`
+	for len(rs) != 0 {
+		chunk := rs[:min(len(rs), batchRows(6))]
+		rs = rs[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*6)
+		for _, r := range chunk {
+			args = append(args, r.ID, r.UUID, r.Name, r.CreatedAt, r.UpdatedAt, r.DeletedAt)
+		}
+		query := sqlstr + batchValues(len(chunk), 6) + conflict
+		// run
+		logf(query, args...)
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return logerror(err)
+		}
+		// set exists
+		for _, r := range chunk {
+			r._exists = true
+		}
+	}
+	return nil
+}
+
+// Delete soft deletes the [Resource] from the database by setting
+// its deleted_at column. Use [Resource.HardDelete] to remove the row.
 func (r *Resource) Delete(ctx context.Context, db DB) error {
+	switch {
+	case !r._exists: // doesn't exist
+		return nil
+	case r.Deleted(): // deleted
+		return nil
+	}
+	// soft delete with primary key
+	const sqlstr = `UPDATE platform.resources SET ` +
+		`deleted_at = ? ` +
+		`WHERE id = ?`
+	// run
+	deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	logf(sqlstr, deletedAt, r.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, deletedAt, r.ID); err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	r.DeletedAt = deletedAt
+	return r.touch(ctx, db)
+}
+
+// Restore restores a soft deleted [Resource] by clearing its
+// deleted_at column.
+func (r *Resource) Restore(ctx context.Context, db DB) error {
+	switch {
+	case !r._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case r._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	case !r.DeletedAt.Valid: // not soft deleted
+		return nil
+	}
+	// restore with primary key
+	const sqlstr = `UPDATE platform.resources SET ` +
+		`deleted_at = ? ` +
+		`WHERE id = ?`
+	// run
+	logf(sqlstr, nil, r.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, nil, r.ID); err != nil {
+		return logerror(err)
+	}
+	// set restored
+	r.DeletedAt = sql.NullTime{}
+	return r.touch(ctx, db)
+}
+
+// HardDelete permanently deletes the [Resource] from the database,
+// whether or not it has been soft deleted.
+func (r *Resource) HardDelete(ctx context.Context, db DB) error {
 	switch {
 	case !r._exists: // doesn't exist
 		return nil
@@ -143,90 +301,151 @@ func (r *Resource) Delete(ctx context.Context, db DB) error {
 //
 // If `order` is `ASC`, it retrieves records where the value of `column` is greater than `key`.
 // If `order` is `DESC`, it retrieves records where the value of `column` is less than `key`.
+// A nil `key` retrieves the first page.
+// Records with equal values of `column` are ordered by `id`: a `key` made by
+// [paginator.AfterRow] from the values of `column` and `id` of the last record
+// resumes after it without skipping the records equal to it.
 //
 // Filters are dynamically provided via a `filters` map, where keys are column names and values are either single values or slices for `IN` clauses.
-func ResourceKeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}) ([]*Resource, *Resource, error) {
-	if order != "ASC" && order != "DESC" {
-		return nil, nil, fmt.Errorf("invalid order: %s", order)
+// Typed conditions such as time ranges are provided via `where`.
+// Invalid columns, orders, limits and filters are reported as a [paginator.FieldError].
+func ResourceKeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]*Resource, *Resource, error) {
+	q := paginator.Query{
+		From:    "resources",
+		Columns: []string{"id", "uuid", "name", "created_at", "updated_at", "deleted_at"},
+		// Exclude soft deleted rows according to the context's scope
+		Where:    append(deletedConditions(ctx, "deleted_at"), where...),
+		Sort:     paginator.SortKey{Column: column, Order: paginator.Order(order)},
+		Tiebreak: "id",
+		Filters:  filters,
+		Limit:    limit,
 	}
-
-	// Start building the query
-	query := fmt.Sprintf(
-		`SELECT * FROM resources 
-         WHERE %s %s ?`,
-		column, condition(order), // Ensure this is returning a valid operator
-	)
-
-	// Arguments for the query
-	args := []interface{}{key}
-
-	// Dynamically add filters from the `filters` map to the query
-	for field, value := range filters {
-		switch v := value.(type) {
-		case []int:
-			if len(v) > 0 {
-				placeholders := make([]string, len(v))
-				for i := range v {
-					placeholders[i] = "?"
-					args = append(args, v[i])
-				}
-				query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
+	page, err := paginator.Fetch(ctx, logQueryer{db}, dialect, q, paginator.After(key), paginator.Mapper[*Resource]{
+		Scan: func(s paginator.Scanner) (*Resource, error) {
+			r := Resource{
+				_exists: true,
 			}
-		case []string:
-			if len(v) > 0 {
-				placeholders := make([]string, len(v))
-				for i := range v {
-					placeholders[i] = "?"
-					args = append(args, v[i])
-				}
-				query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
-			}
-		default:
-			query += fmt.Sprintf(" AND %s = ?", field)
-			args = append(args, value)
-		}
+			err := s.Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt)
+			return &r, err
+		},
+	})
+	if err != nil {
+		return nil, nil, logerror(err)
 	}
+	// the last record is the key for the next page
+	last, _ := page.Last()
+	return page.Items, last, nil
+}
 
-	// Finalize the query with the order and limit
-	query += fmt.Sprintf(" ORDER BY %s %s LIMIT ?", column, order)
-	args = append(args, limit)
+// ResourceCollection describes the 'platform.resources' table paged by [ResourceKeysetPage].
+//
+// Generated from the columns and indexes of 'resources'.
+var ResourceCollection = &Collection{
+	Name: "resources",
+	Fields: []CollectionField{
+		{Name: "id", Type: "int", GoType: "int", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "uuid", Type: "varchar", GoType: "string", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "name", Type: "varchar", GoType: "string", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "created_at", Type: "timestamp", GoType: "time.Time", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "updated_at", Type: "timestamp", GoType: "time.Time", Nullable: false, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">="}},
+		{Name: "deleted_at", Type: "timestamp", GoType: "sql.NullTime", Nullable: true, Operators: []string{"=", "<>", "IN", "<", "<=", ">", ">=", "IS NULL", "IS NOT NULL"}},
+	},
+	SortKeys: []string{"created_at", "id", "name", "uuid"},
+}
+
+func init() {
+	registerCollection(ResourceCollection)
+}
 
-	// Log the final query for debugging purposes
-	log.Printf("Executing query: %s with args: %v", query, args)
+// ResourceWatermark is a position in the [Resource] change feed: the updated_at and
+// id of the last change read. The zero value starts from the first change.
+type ResourceWatermark struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        int       `json:"id"`
+}
 
-	// Execute the query
-	rows, err := db.QueryContext(ctx, query, args...)
+// ResourceChangesSince retrieves up to `limit` [Resource] records changed after the watermark,
+// ordered by (updated_at, id), with the watermark to resume from. The watermark
+// is returned unchanged when there are no new changes.
+//
+// The updated_at of a change is set by the database (ON UPDATE CURRENT_TIMESTAMP(6)) when
+// its statement runs, not when its transaction commits: a change committed after a later
+// change has been read falls behind the watermark and is skipped. Consumers that need every
+// change should resume from a watermark moved back by the longest transaction, and skip the
+// records read again.
+//
+// Soft deleted records are returned whatever the context's [DeletedScope], so that
+// deletions are reported: check [Resource.Deleted]. Rows removed with
+// [Resource.HardDelete] are not reported.
+func ResourceChangesSince(ctx context.Context, db DB, watermark ResourceWatermark, limit int) ([]*Resource, ResourceWatermark, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`id, uuid, name, created_at, updated_at, deleted_at ` +
+		`FROM platform.resources ` +
+		`WHERE (updated_at > ? OR (updated_at = ? AND id > ?)) ` +
+		`ORDER BY updated_at, id ` +
+		`LIMIT ?`
+	// run
+	rows, err := logQueryer{db}.QueryContext(ctx, sqlstr, watermark.UpdatedAt, watermark.UpdatedAt, watermark.ID, limit)
 	if err != nil {
-		return nil, nil, logerror(err)
+		return nil, watermark, logerror(err)
 	}
 	defer rows.Close()
-
-	var results []*Resource
-	var lastItem *Resource // Variable to store the last item
-
+	// process
+	var res []*Resource
 	for rows.Next() {
 		r := Resource{
 			_exists: true,
 		}
-		if err := rows.Scan(
-			&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt,
-		); err != nil {
-			return nil, nil, logerror(err)
+		// scan
+		if err := rows.Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt); err != nil {
+			return nil, watermark, logerror(err)
 		}
-		results = append(results, &r)
+		res = append(res, &r)
 	}
-
-	// Check for errors during row iteration.
 	if err := rows.Err(); err != nil {
-		return nil, nil, logerror(err)
+		return nil, watermark, logerror(err)
 	}
-
-	// If we have results, set the lastItem to the last element in results.
-	if len(results) > 0 {
-		lastItem = results[len(results)-1]
+	// resume after the last change
+	if len(res) > 0 {
+		last := res[len(res)-1]
+		watermark = ResourceWatermark{UpdatedAt: last.UpdatedAt, ID: last.ID}
 	}
+	return res, watermark, nil
+}
 
-	return results, lastItem, nil
+// ResourcesByCreatedAt retrieves a row from 'platform.resources' as a [Resource].
+//
+// Generated from index 'resources_created_at_idx'.
+func ResourcesByCreatedAt(ctx context.Context, db DB, createdAt time.Time) ([]*Resource, error) {
+	// query
+	sqlstr := `SELECT ` +
+		`id, uuid, name, created_at, updated_at, deleted_at ` +
+		`FROM platform.resources ` +
+		`WHERE created_at = ?` +
+		deletedClause(ctx, "deleted_at")
+	// run
+	rows, err := selectRows(ctx, db, sqlstr, createdAt)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*Resource
+	for rows.Next() {
+		r := Resource{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
 }
 
 // ResourceByID retrieves a row from 'platform.resources' as a [Resource].
@@ -234,36 +453,70 @@ func ResourceKeysetPage(ctx context.Context, db DB, column string, key interface
 // Generated from index 'resources_id_pkey'.
 func ResourceByID(ctx context.Context, db DB, id int) (*Resource, error) {
 	// query
-	const sqlstr = `SELECT ` +
-		`id, uuid, name, created_at, updated_at ` +
+	sqlstr := `SELECT ` +
+		`id, uuid, name, created_at, updated_at, deleted_at ` +
 		`FROM platform.resources ` +
-		`WHERE id = ?`
+		`WHERE id = ?` +
+		deletedClause(ctx, "deleted_at")
 	// run
-	logf(sqlstr, id)
 	r := Resource{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt); err != nil {
+	if err := selectRow(ctx, db, sqlstr, id).Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &r, nil
 }
 
+// ResourcesByName retrieves a row from 'platform.resources' as a [Resource].
+//
+// Generated from index 'resources_name_idx'.
+func ResourcesByName(ctx context.Context, db DB, name string) ([]*Resource, error) {
+	// query
+	sqlstr := `SELECT ` +
+		`id, uuid, name, created_at, updated_at, deleted_at ` +
+		`FROM platform.resources ` +
+		`WHERE name = ?` +
+		deletedClause(ctx, "deleted_at")
+	// run
+	rows, err := selectRows(ctx, db, sqlstr, name)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*Resource
+	for rows.Next() {
+		r := Resource{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // ResourceByUUID retrieves a row from 'platform.resources' as a [Resource].
 //
 // Generated from index 'uuid'.
 func ResourceByUUID(ctx context.Context, db DB, uuid string) (*Resource, error) {
 	// query
-	const sqlstr = `SELECT ` +
-		`id, uuid, name, created_at, updated_at ` +
+	sqlstr := `SELECT ` +
+		`id, uuid, name, created_at, updated_at, deleted_at ` +
 		`FROM platform.resources ` +
-		`WHERE uuid = ?`
+		`WHERE uuid = ?` +
+		deletedClause(ctx, "deleted_at")
 	// run
-	logf(sqlstr, uuid)
 	r := Resource{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, uuid).Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt); err != nil {
+	if err := selectRow(ctx, db, sqlstr, uuid).Scan(&r.ID, &r.UUID, &r.Name, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &r, nil
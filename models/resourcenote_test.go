@@ -0,0 +1,146 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/paginator"
+)
+
+// TestResourceNotesByResourceIDPage tests paging the notes of a resource on
+// the foreign key index, across notes created at the same time.
+func TestResourceNotesByResourceIDPage(t *testing.T) {
+	db, err := initResourceNotesTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var ids []int
+	var key interface{}
+	for page := 0; ; page++ {
+		notes, last, err := ResourceNotesByResourceIDPage(ctx, db, 1, "created_at", key, 2, "ASC", nil)
+		if err != nil {
+			t.Fatalf("Failed to get page %d: %v", page, err)
+		}
+		for _, n := range notes {
+			if n.ResourceID != 1 {
+				t.Errorf("Expected the notes of resource 1, got: %+v", n)
+			}
+			ids = append(ids, n.ID)
+		}
+		if len(notes) < 2 {
+			break
+		}
+		key = paginator.AfterRow(last.CreatedAt, last.ID)
+	}
+	if got := fmt.Sprint(ids); got != "[1 2 4 5 3]" {
+		t.Errorf("Expected the notes ordered by created_at and id, got: %s", got)
+	}
+
+	// the page can only be sorted on the index following the foreign key
+	var fe *paginator.FieldError
+	if _, _, err := ResourceNotesByResourceIDPage(ctx, db, 1, "body", nil, 2, "ASC", nil); !errors.As(err, &fe) || fe.Field != "column" {
+		t.Errorf("Expected a column error for an unindexed sort column, got: %v", err)
+	}
+
+	note, err := ResourceNoteByID(ctx, db, 6)
+	if err != nil {
+		t.Fatalf("Failed to get note 6: %v", err)
+	}
+	resource, err := note.Resource(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get the resource of note 6: %v", err)
+	}
+	if resource.ID != 2 {
+		t.Errorf("Expected resource 2, got: %+v", resource)
+	}
+}
+
+// Initialize an in-memory SQLite database with the resources of initTestDB
+// and the notes of resources 1 and 2.
+func initResourceNotesTestDB() (*sql.DB, error) {
+	db, err := initTestDB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the resource_notes table schema
+	_, err = db.Exec(`
+		CREATE TABLE platform.resource_notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			resource_id INTEGER NOT NULL REFERENCES resources (id),
+			body TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Insert the notes, three of resource 1 created at the same time
+	sampleData := []struct {
+		ResourceID int
+		CreatedAt  string
+	}{
+		{1, "2024-09-25T10:00:00Z"},
+		{1, "2024-09-25T10:05:00Z"},
+		{1, "2024-09-25T10:10:00Z"},
+		{1, "2024-09-25T10:05:00Z"},
+		{1, "2024-09-25T10:05:00Z"},
+		{2, "2024-09-25T10:05:00Z"},
+	}
+	for i, data := range sampleData {
+		rn := &ResourceNote{ResourceID: data.ResourceID, Body: fmt.Sprintf("Note %d", i+1), CreatedAt: parseTime(data.CreatedAt)}
+		if err := rn.Insert(context.Background(), db); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// TestResourceNoteCountsKeysetPage tests paging the custom query on its
+// keyset, across the resources with the same number of notes.
+func TestResourceNoteCountsKeysetPage(t *testing.T) {
+	db, err := initResourceNotesTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var pages []string
+	var key interface{}
+	for {
+		counts, last, err := ResourceNoteCountsKeysetPage(ctx, db, parseTime("2024-09-25T10:00:00Z"), "notes", key, 2, "ASC", nil)
+		if err != nil {
+			t.Fatalf("Failed to get page %d: %v", len(pages), err)
+		}
+		var page []string
+		for _, c := range counts {
+			page = append(page, fmt.Sprintf("%d:%d", c.ID, c.Notes))
+		}
+		pages = append(pages, strings.Join(page, " "))
+		if len(counts) < 2 {
+			break
+		}
+		key = paginator.AfterRow(last.Notes, last.ID)
+	}
+	if got := strings.Join(pages, " | "); got != "3:0 4:0 | 5:0 2:1 | 1:5" {
+		t.Errorf("Expected the resources ordered by notes and id, got: %s", got)
+	}
+
+	// the tiebreak only orders the keyset columns
+	var fe *paginator.FieldError
+	if _, _, err := ResourceNoteCountsKeysetPage(ctx, db, time.Time{}, "id", nil, 2, "ASC", nil); !errors.As(err, &fe) || fe.Field != "column" {
+		t.Errorf("Expected a column error for a column outside of the keyset, got: %v", err)
+	}
+}
@@ -3,8 +3,11 @@ package models
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,21 +20,42 @@ func initTestDB() (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The attached database only lives on this connection.
+	db.SetMaxOpenConns(1)
+
+	// Attach the 'platform' schema used by the generated CRUD and index queries;
+	// unqualified table names resolve to it as well.
+	if _, err := db.Exec(`ATTACH DATABASE ':memory:' AS platform`); err != nil {
+		return nil, err
+	}
 
 	// Create the resources table schema
 	_, err = db.Exec(`
-		CREATE TABLE resources (
+		CREATE TABLE platform.resources (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			uuid VARCHAR(100) NOT NULL UNIQUE,
 			name VARCHAR(100) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL DEFAULT NULL
 		)
 	`)
 	if err != nil {
 		return nil, err
 	}
 
+	// Set updated_at on changes as ON UPDATE CURRENT_TIMESTAMP(6) does
+	_, err = db.Exec(`
+		CREATE TRIGGER platform.resources_updated_at AFTER UPDATE ON resources
+		WHEN NEW.updated_at IS OLD.updated_at
+		BEGIN
+			UPDATE resources SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+		END
+	`)
+	if err != nil {
+		return nil, err
+	}
+
 	// Insert sample data into the table using fixed times
 	sampleData := []struct {
 		UUID, Name string
@@ -44,11 +68,12 @@ func initTestDB() (*sql.DB, error) {
 		{"uuid-5", "Resource 5", parseTime("2024-09-25T10:20:00Z")},
 	}
 
-	for _, data := range sampleData {
-		_, err := db.Exec(`INSERT INTO resources (uuid, name, created_at) VALUES (?, ?, ?)`, data.UUID, data.Name, data.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
+	resources := make([]*Resource, len(sampleData))
+	for i, data := range sampleData {
+		resources[i] = &Resource{UUID: data.UUID, Name: data.Name, CreatedAt: data.CreatedAt}
+	}
+	if err := InsertResourceBatch(context.Background(), db, resources); err != nil {
+		return nil, err
 	}
 
 	return db, nil
@@ -308,6 +333,332 @@ func TestResourceKeysetPagePagination(t *testing.T) {
 	}
 }
 
+// TestResourceSoftDelete tests that Delete soft deletes a resource and that the
+// deleted scope controls whether lookups and keyset pages return it.
+func TestResourceSoftDelete(t *testing.T) {
+	db, err := initTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	r, err := ResourceByID(ctx, db, 2)
+	if err != nil {
+		t.Fatalf("Failed to fetch resource 2: %v", err)
+	}
+	if err := r.Delete(ctx, db); err != nil {
+		t.Fatalf("Failed to soft delete resource 2: %v", err)
+	}
+	if !r.DeletedAt.Valid {
+		t.Errorf("Expected DeletedAt to be set after Delete")
+	}
+
+	// Updating a soft deleted row is refused
+	r.Name = "Renamed"
+	if err := r.Update(ctx, db); !errors.Is(err, ErrMarkedForDeletion) {
+		t.Errorf("Expected ErrMarkedForDeletion updating a deleted resource, got: %v", err)
+	}
+
+	// Soft deleted rows are hidden by default
+	if _, err := ResourceByID(ctx, db, 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a deleted resource, got: %v", err)
+	}
+	page, _, err := ResourceKeysetPage(ctx, db, "id", 0, 10, "ASC", nil)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(page) != 4 {
+		t.Errorf("Expected 4 resources excluding the deleted one, got: %s", printResources(page))
+	}
+
+	// IncludeDeleted finds the row again
+	found, err := ResourceByID(WithDeletedScope(ctx, IncludeDeleted), db, 2)
+	if err != nil {
+		t.Fatalf("Failed to fetch deleted resource with IncludeDeleted: %v", err)
+	}
+	if !found.DeletedAt.Valid {
+		t.Errorf("Expected fetched resource to have DeletedAt set")
+	}
+
+	// OnlyDeleted returns just the deleted row
+	page, _, err = ResourceKeysetPage(WithDeletedScope(ctx, OnlyDeleted), db, "id", 0, 10, "ASC", nil)
+	if err != nil {
+		t.Fatalf("Failed to get page of deleted resources: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != 2 {
+		t.Errorf("Expected only resource 2, got: %s", printResources(page))
+	}
+
+	// Restore makes the row visible again
+	if err := found.Restore(ctx, db); err != nil {
+		t.Fatalf("Failed to restore resource 2: %v", err)
+	}
+	if _, err := ResourceByID(ctx, db, 2); err != nil {
+		t.Errorf("Expected restored resource to be visible, got: %v", err)
+	}
+
+	// HardDelete removes the row regardless of scope
+	if err := found.HardDelete(ctx, db); err != nil {
+		t.Fatalf("Failed to hard delete resource 2: %v", err)
+	}
+	if _, err := ResourceByID(WithDeletedScope(ctx, IncludeDeleted), db, 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows after HardDelete, got: %v", err)
+	}
+}
+
+// firstInsertIDs reports the id of the first row inserted by a statement as
+// its LastInsertId, as MySQL does, where SQLite reports the id of the last one.
+type firstInsertIDs struct {
+	DB
+}
+
+// ExecContext satisfies the [DB] interface.
+func (db firstInsertIDs) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	return firstInsertIDResult{res, id - n + 1}, nil
+}
+
+// firstInsertIDResult is a [sql.Result] with the id of the first inserted row.
+type firstInsertIDResult struct {
+	sql.Result
+	id int64
+}
+
+// LastInsertId satisfies the [sql.Result] interface.
+func (res firstInsertIDResult) LastInsertId() (int64, error) {
+	return res.id, nil
+}
+
+// TestInsertResourceBatch tests that InsertResourceBatch inserts every row with
+// multi-row statements, and sets the ids of the stored rows on the resources.
+func TestInsertResourceBatch(t *testing.T) {
+	db, err := initTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Split the batch into chunks of 2 rows, with the first ids reported as
+	// MySQL does.
+	defer func(f func(int) int) { batchRows = f }(batchRows)
+	batchRows = func(int) int { return 2 }
+	mysql := firstInsertIDs{db}
+
+	n := 25
+	resources := make([]*Resource, n)
+	for i := range resources {
+		resources[i] = &Resource{
+			UUID:      fmt.Sprintf("batch-uuid-%d", i),
+			Name:      fmt.Sprintf("Batch %d", i),
+			CreatedAt: parseTime("2024-09-26T10:00:00Z").Add(time.Duration(i) * time.Second),
+		}
+	}
+	if err := InsertResourceBatch(ctx, mysql, resources); err != nil {
+		t.Fatalf("Failed to insert batch: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM resources WHERE uuid LIKE 'batch-uuid-%'`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count inserted resources: %v", err)
+	}
+	if count != n {
+		t.Errorf("Expected %d inserted resources, got: %d", n, count)
+	}
+	for _, r := range resources {
+		if !r.Exists() {
+			t.Fatalf("Expected %s to exist after insert", r.UUID)
+		}
+		var id int
+		if err := db.QueryRow(`SELECT id FROM resources WHERE uuid = ?`, r.UUID).Scan(&id); err != nil {
+			t.Fatalf("Failed to fetch the id of %s: %v", r.UUID, err)
+		}
+		if r.ID != id {
+			t.Errorf("Expected %s to have the id %d of its row, got: %d", r.UUID, id, r.ID)
+		}
+	}
+
+	// Inserting the same rows again is refused
+	if err := InsertResourceBatch(ctx, mysql, resources[:1]); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists inserting an existing resource, got: %v", err)
+	}
+}
+
+// TestResourceChangesSince tests paging through the change feed and resuming
+// from the returned watermark after a soft delete.
+func TestResourceChangesSince(t *testing.T) {
+	db, err := initTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Page through the initial rows, ordered by (updated_at, id)
+	var watermark ResourceWatermark
+	var ids []int
+	for {
+		changes, next, err := ResourceChangesSince(ctx, db, watermark, 2)
+		if err != nil {
+			t.Fatalf("Failed to get changes: %v", err)
+		}
+		if len(changes) == 0 {
+			if next != watermark {
+				t.Errorf("Expected unchanged watermark %+v, got: %+v", watermark, next)
+			}
+			break
+		}
+		for _, r := range changes {
+			ids = append(ids, r.ID)
+		}
+		watermark = next
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("Expected changes [1 2 3 4 5], got: %v", ids)
+	}
+
+	// A soft delete is reported after the watermark as a deleted record
+	r, err := ResourceByID(ctx, db, 3)
+	if err != nil {
+		t.Fatalf("Failed to fetch resource 3: %v", err)
+	}
+	if err := r.Delete(ctx, db); err != nil {
+		t.Fatalf("Failed to soft delete resource 3: %v", err)
+	}
+	changes, _, err := ResourceChangesSince(ctx, db, watermark, 2)
+	if err != nil {
+		t.Fatalf("Failed to get changes after delete: %v", err)
+	}
+	if len(changes) != 1 || changes[0].ID != 3 || !changes[0].Deleted() {
+		t.Fatalf("Expected deleted resource 3, got: %s", printResources(changes))
+	}
+	if !r.UpdatedAt.Equal(changes[0].UpdatedAt) {
+		t.Errorf("Expected the updated_at %v set by the database, got: %v", changes[0].UpdatedAt, r.UpdatedAt)
+	}
+
+	// An update is stamped by the database, whatever the updated_at of the record
+	r, err = ResourceByID(ctx, db, 4)
+	if err != nil {
+		t.Fatalf("Failed to fetch resource 4: %v", err)
+	}
+	r.Name, r.UpdatedAt = "Renamed", parseTime("2000-01-01T00:00:00Z")
+	if err := r.Update(ctx, db); err != nil {
+		t.Fatalf("Failed to update resource 4: %v", err)
+	}
+	changes, _, err = ResourceChangesSince(ctx, db, watermark, 2)
+	if err != nil {
+		t.Fatalf("Failed to get changes after update: %v", err)
+	}
+	if len(changes) != 2 || changes[1].ID != 4 || !r.UpdatedAt.Equal(changes[1].UpdatedAt) {
+		t.Errorf("Expected resource 4 updated at %v after resource 3, got: %s", r.UpdatedAt, printResources(changes))
+	}
+}
+
+// TestPageExecutionTime tests that the page queries and the index lookups are
+// hinted with the deadline of the context.
+func TestPageExecutionTime(t *testing.T) {
+	db, err := initTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+
+	var queries []string
+	SetLogger(func(query string, _ ...interface{}) { queries = append(queries, query) })
+	defer SetLogger(func(string, ...interface{}) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, _, err := ResourceKeysetPage(ctx, db, "name", nil, 2, "ASC", nil); err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if _, _, err := ResourceChangesSince(ctx, db, ResourceWatermark{}, 2); err != nil {
+		t.Fatalf("Failed to get changes: %v", err)
+	}
+	if _, _, err := ResourceKeysetPage(context.Background(), db, "name", nil, 2, "ASC", nil); err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	r, err := ResourceByUUID(ctx, db, "uuid-2")
+	if err != nil || r.ID != 2 {
+		t.Fatalf("Expected resource 2 by UUID, got: %v, %v", r, err)
+	}
+	if _, err := ResourcesByName(ctx, db, "Resource 3"); err != nil {
+		t.Fatalf("Failed to get resources by name: %v", err)
+	}
+	if _, err := ResourceByUUID(context.Background(), db, "uuid-2"); err != nil {
+		t.Fatalf("Failed to get resource by UUID: %v", err)
+	}
+	if len(queries) != 6 {
+		t.Fatalf("Expected 6 queries, got: %v", queries)
+	}
+	for i, hinted := range []bool{true, true, false, true, true, false} {
+		if strings.HasPrefix(queries[i], "SELECT /*+ MAX_EXECUTION_TIME(") != hinted {
+			t.Errorf("Expected query %d to be hinted: %v, got: %s", i, hinted, queries[i])
+		}
+	}
+}
+
+// TestCollections tests the collections generated from the columns and indexes
+// of the tables, and the lookups of the non-unique indexes.
+func TestCollections(t *testing.T) {
+	var names []string
+	for _, c := range Collections() {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "animal_rankings,resource_notes,resources" {
+		t.Errorf("Expected the collections of the tables, got: %s", got)
+	}
+	c, ok := CollectionByName("resources")
+	if !ok {
+		t.Fatalf("Expected the resources collection")
+	}
+	if got := strings.Join(c.SortKeys, ","); got != "created_at,id,name,uuid" {
+		t.Errorf("Expected the indexed columns as sort keys, got: %s", got)
+	}
+	deletedAt, ok := c.Field("deleted_at")
+	if !ok || !deletedAt.Nullable || deletedAt.GoType != "sql.NullTime" || !slices.Contains(deletedAt.Operators, "IS NULL") {
+		t.Errorf("Expected deleted_at to be nullable, got: %+v", deletedAt)
+	}
+	if name, _ := c.Field("name"); name.Nullable || slices.Contains(name.Operators, "IS NULL") {
+		t.Errorf("Expected name not to be nullable, got: %+v", name)
+	}
+
+	db, err := initTestDB()
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	resources, err := ResourcesByName(ctx, db, "Resource 2")
+	if err != nil {
+		t.Fatalf("Failed to get resources by name: %v", err)
+	}
+	if len(resources) != 1 || resources[0].UUID != "uuid-2" {
+		t.Fatalf("Expected resource 2, got: %s", printResources(resources))
+	}
+	if err := resources[0].Delete(ctx, db); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	if resources, err = ResourcesByName(ctx, db, "Resource 2"); err != nil || len(resources) != 0 {
+		t.Errorf("Expected the soft deleted resource to be excluded, got: %s, %v", printResources(resources), err)
+	}
+}
+
 // Utility function to print the Resource slice for debugging.
 func printResources(resources []*Resource) string {
 	var output string
@@ -0,0 +1,92 @@
+package paginator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect renders the database specific parts of a keyset paginated query.
+type Dialect interface {
+	// Placeholder returns the placeholder of the n-th (1-based) query argument.
+	Placeholder(n int) string
+	// Quote quotes an identifier.
+	Quote(ident string) string
+	// Limit returns the clause following ORDER BY that limits the number of
+	// rows to the argument bound to placeholder.
+	Limit(placeholder string) string
+}
+
+// ExecutionTimeLimiter is implemented by the dialects that can make the
+// database abort a SELECT running for too long.
+type ExecutionTimeLimiter interface {
+	// LimitExecutionTime returns the SELECT query aborted after timeout.
+	LimitExecutionTime(query string, timeout time.Duration) string
+}
+
+// LimitExecutionTime returns the SELECT query aborted by the database after
+// timeout, if the dialect supports it, and the query unchanged otherwise.
+func LimitExecutionTime(d Dialect, query string, timeout time.Duration) string {
+	if l, ok := d.(ExecutionTimeLimiter); ok {
+		return l.LimitExecutionTime(query, timeout)
+	}
+	return query
+}
+
+// Dialects.
+var (
+	// MySQL is the MySQL and MariaDB dialect.
+	MySQL Dialect = mysql{}
+	// Postgres is the PostgreSQL dialect.
+	Postgres Dialect = postgres{}
+	// SQLite is the SQLite3 dialect.
+	SQLite Dialect = sqlite{}
+	// SQLServer is the Microsoft SQL Server dialect.
+	SQLServer Dialect = sqlserver{}
+	// Oracle is the Oracle dialect (12c and later).
+	Oracle Dialect = oracle{}
+)
+
+type mysql struct{}
+
+func (mysql) Placeholder(int) string    { return "?" }
+func (mysql) Quote(ident string) string { return "`" + ident + "`" }
+func (mysql) Limit(p string) string     { return "LIMIT " + p }
+
+// LimitExecutionTime adds the MAX_EXECUTION_TIME optimizer hint, in
+// milliseconds, to the query.
+func (mysql) LimitExecutionTime(query string, timeout time.Duration) string {
+	rest, ok := strings.CutPrefix(query, "SELECT ")
+	if !ok {
+		return query
+	}
+	ms := max(timeout.Milliseconds(), 1)
+	return "SELECT /*+ MAX_EXECUTION_TIME(" + strconv.FormatInt(ms, 10) + ") */ " + rest
+}
+
+type postgres struct{}
+
+func (postgres) Placeholder(n int) string  { return "$" + strconv.Itoa(n) }
+func (postgres) Quote(ident string) string { return `"` + ident + `"` }
+func (postgres) Limit(p string) string     { return "LIMIT " + p }
+
+type sqlite struct{}
+
+func (sqlite) Placeholder(int) string    { return "?" }
+func (sqlite) Quote(ident string) string { return `"` + ident + `"` }
+func (sqlite) Limit(p string) string     { return "LIMIT " + p }
+
+type sqlserver struct{}
+
+func (sqlserver) Placeholder(n int) string  { return "@p" + strconv.Itoa(n) }
+func (sqlserver) Quote(ident string) string { return "[" + ident + "]" }
+func (sqlserver) Limit(p string) string     { return "OFFSET 0 ROWS FETCH NEXT " + p + " ROWS ONLY" }
+
+type oracle struct{}
+
+func (oracle) Placeholder(n int) string { return ":" + strconv.Itoa(n) }
+
+// Quote leaves identifiers unquoted, as quoted Oracle identifiers are case
+// sensitive.
+func (oracle) Quote(ident string) string { return ident }
+func (oracle) Limit(p string) string     { return "FETCH NEXT " + p + " ROWS ONLY" }
@@ -0,0 +1,131 @@
+package paginator
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Condition is a predicate on a column, such as a filter or a scope added to
+// every page of a query.
+type Condition struct {
+	Column string
+	// Op is the SQL operator: "=", "<>", "<", "<=", ">", ">=", "IN", "IS NULL"
+	// or "IS NOT NULL".
+	Op string
+	// Values are the arguments of the operator: none for IS NULL and IS NOT
+	// NULL, one or more for IN, and one otherwise.
+	Values []interface{}
+}
+
+// Eq returns the condition column = v.
+func Eq(column string, v interface{}) Condition {
+	return Condition{Column: column, Op: "=", Values: []interface{}{v}}
+}
+
+// In returns the condition column IN (values...).
+func In(column string, values ...interface{}) Condition {
+	return Condition{Column: column, Op: "IN", Values: values}
+}
+
+// Gt returns the condition column > v.
+func Gt(column string, v interface{}) Condition {
+	return Condition{Column: column, Op: ">", Values: []interface{}{v}}
+}
+
+// Ge returns the condition column >= v.
+func Ge(column string, v interface{}) Condition {
+	return Condition{Column: column, Op: ">=", Values: []interface{}{v}}
+}
+
+// Lt returns the condition column < v.
+func Lt(column string, v interface{}) Condition {
+	return Condition{Column: column, Op: "<", Values: []interface{}{v}}
+}
+
+// Le returns the condition column <= v.
+func Le(column string, v interface{}) Condition {
+	return Condition{Column: column, Op: "<=", Values: []interface{}{v}}
+}
+
+// IsNull returns the condition column IS NULL.
+func IsNull(column string) Condition {
+	return Condition{Column: column, Op: "IS NULL"}
+}
+
+// IsNotNull returns the condition column IS NOT NULL.
+func IsNotNull(column string) Condition {
+	return Condition{Column: column, Op: "IS NOT NULL"}
+}
+
+// validate checks the operator and its number of values.
+func (c Condition) validate() error {
+	switch c.Op {
+	case "IS NULL", "IS NOT NULL":
+		if len(c.Values) == 0 {
+			return nil
+		}
+	case "IN":
+		if len(c.Values) > 0 {
+			return nil
+		}
+	case "=", "<>", "<", "<=", ">", ">=":
+		if len(c.Values) == 1 {
+			return nil
+		}
+	default:
+		return &FieldError{Field: "filters", Description: "unsupported operator " + c.Op}
+	}
+	return &FieldError{Field: "filters", Description: "wrong number of values for " + c.Column + " " + c.Op}
+}
+
+// render renders the condition, binding its values to the placeholders
+// returned by bind.
+func (c Condition) render(d Dialect, bind func(interface{}) string) string {
+	col := d.Quote(c.Column)
+	switch c.Op {
+	case "IS NULL", "IS NOT NULL":
+		return col + " " + c.Op
+	case "IN":
+		placeholders := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			placeholders[i] = bind(v)
+		}
+		return col + " IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	return col + " " + c.Op + " " + bind(c.Values[0])
+}
+
+// Filters maps column names to the value they must equal. A slice value
+// matches any of its elements with an IN predicate; an empty slice does not
+// filter the column.
+type Filters map[string]interface{}
+
+// Conditions returns the conditions of the filters, ordered by column so the
+// rendered query is stable.
+func (f Filters) Conditions() []Condition {
+	columns := make([]string, 0, len(f))
+	for column := range f {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	var conds []Condition
+	for _, column := range columns {
+		v := f[column]
+		rv := reflect.ValueOf(v)
+		// []byte is a single value
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+			conds = append(conds, Eq(column, v))
+			continue
+		}
+		if rv.Len() == 0 {
+			continue
+		}
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+		conds = append(conds, In(column, values...))
+	}
+	return conds
+}
@@ -0,0 +1,292 @@
+// Package paginator implements keyset pagination over SQL tables and
+// subqueries.
+//
+// A [Query] selects the rows after a [Cursor] in the order of a [SortKey],
+// with ties broken by a unique column, restricted by [Filters] and scope
+// [Condition]s, and is rendered for a [Dialect]. [Fetch] runs it and maps the
+// rows to a typed [Page]. The generated models use it for their KeysetPage
+// functions, and hand-written repositories can use it the same way.
+package paginator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Order is the direction of a keyset.
+type Order string
+
+// Order values.
+const (
+	// Asc pages through increasing values of the sort column.
+	Asc Order = "ASC"
+	// Desc pages through decreasing values of the sort column.
+	Desc Order = "DESC"
+)
+
+// SortKey is the column and direction a query is ordered and paged by.
+type SortKey struct {
+	Column string
+	Order  Order
+}
+
+// comparison returns the operator selecting the rows after a cursor.
+func (k SortKey) comparison() string {
+	if k.Order == Desc {
+		return "<"
+	}
+	return ">"
+}
+
+// Cursor is a position in a keyset: the sort column value of the last row of
+// the previous page, and its tiebreak column value. The zero Cursor starts from
+// the first row.
+type Cursor struct {
+	Value interface{}
+	// Tiebreak is the value of the tiebreak column of the row. When nil, the
+	// rows after the cursor are the rows after Value, skipping the other rows
+	// equal to Value.
+	Tiebreak interface{}
+}
+
+// After returns the cursor of the rows after v. A nil v is the zero Cursor,
+// and a Cursor v is returned unchanged.
+func After(v interface{}) Cursor {
+	if c, ok := v.(Cursor); ok {
+		return c
+	}
+	return Cursor{Value: v}
+}
+
+// AfterRow returns the cursor of the rows after the row of the sort column
+// value v and tiebreak column value tiebreak.
+func AfterRow(v, tiebreak interface{}) Cursor {
+	return Cursor{Value: v, Tiebreak: tiebreak}
+}
+
+// IsZero reports whether c starts from the first row.
+func (c Cursor) IsZero() bool {
+	return c.Value == nil
+}
+
+// Page is a page of keyset paginated rows.
+type Page[T any] struct {
+	Items []T
+	// Next is the cursor of the following page, the zero Cursor when the page
+	// is empty.
+	Next Cursor
+}
+
+// Last returns the last item of the page.
+func (p Page[T]) Last() (T, bool) {
+	if len(p.Items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return p.Items[len(p.Items)-1], true
+}
+
+// FieldError is the error returned for an invalid field of a [Query], such as
+// an unknown sort column or filter.
+type FieldError struct {
+	// Field is the invalid field: "column", "order", "limit" or "filters".
+	Field       string
+	Description string
+}
+
+// Error satisfies the error interface.
+func (err *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", err.Field, err.Description)
+}
+
+// Query is a keyset paginated query on a table or subquery.
+type Query struct {
+	// From is the table name, or a parenthesized subquery and its alias.
+	From string
+	// FromArgs are the arguments of the placeholders in From.
+	FromArgs []interface{}
+	// Columns are the selected columns, in scan order, which can be filtered
+	// on. When empty, all columns are selected and any column can be filtered
+	// on.
+	Columns []string
+	// Keys are the columns the query can be sorted on. When empty, any of
+	// Columns can.
+	Keys []string
+	// Where are conditions added to every page, such as soft delete or tenant
+	// scopes and range filters. Their columns must be in Columns.
+	Where []Condition
+	Sort  SortKey
+	// Tiebreak is a unique column, such as the primary key, ordering the rows
+	// with equal values of the sort column, in the order of Sort. It must be
+	// in Columns. When empty, the values of the sort column must be unique
+	// for pages not to skip rows.
+	Tiebreak string
+	// Filters are the conditions requested by the caller.
+	Filters Filters
+	Limit   int
+}
+
+// identRE matches the identifiers accepted as column names.
+var identRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validate checks the sort key, filters, conditions and limit of the query.
+func (q Query) validate() error {
+	keys := q.Keys
+	if len(keys) == 0 {
+		keys = q.Columns
+	}
+	switch {
+	case !validColumn(q.Sort.Column, keys):
+		return &FieldError{Field: "column", Description: fmt.Sprintf("cannot sort by %q", q.Sort.Column)}
+	case q.Sort.Order != Asc && q.Sort.Order != Desc:
+		return &FieldError{Field: "order", Description: fmt.Sprintf("%q is not ASC or DESC", q.Sort.Order)}
+	case q.Tiebreak != "" && !validColumn(q.Tiebreak, q.Columns):
+		return &FieldError{Field: "column", Description: fmt.Sprintf("cannot break ties by %q", q.Tiebreak)}
+	case q.Limit < 0:
+		return &FieldError{Field: "limit", Description: "must not be negative"}
+	}
+	for column := range q.Filters {
+		if !validColumn(column, q.Columns) {
+			return &FieldError{Field: "filters", Description: fmt.Sprintf("cannot filter on %q", column)}
+		}
+	}
+	for _, c := range q.Where {
+		if !validColumn(c.Column, q.Columns) {
+			return &FieldError{Field: "filters", Description: fmt.Sprintf("cannot filter on %q", c.Column)}
+		}
+	}
+	return nil
+}
+
+// validColumn reports whether column is a valid identifier in columns, or any
+// valid identifier when columns is empty.
+func validColumn(column string, columns []string) bool {
+	if !identRE.MatchString(column) {
+		return false
+	}
+	if len(columns) == 0 {
+		return true
+	}
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// tiebreaks reports whether the rows are ordered by the tiebreak column after
+// the sort column.
+func (q Query) tiebreaks() bool {
+	return q.Tiebreak != "" && q.Tiebreak != q.Sort.Column
+}
+
+// Build returns the SQL and arguments selecting the page of the query after
+// cursor.
+func (q Query) Build(d Dialect, cursor Cursor) (string, []interface{}, error) {
+	if err := q.validate(); err != nil {
+		return "", nil, err
+	}
+	args := append([]interface{}(nil), q.FromArgs...)
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return d.Placeholder(len(args))
+	}
+	// select
+	columns := "*"
+	if len(q.Columns) != 0 {
+		quoted := make([]string, len(q.Columns))
+		for i, c := range q.Columns {
+			quoted[i] = d.Quote(c)
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+	// where
+	sortColumn, op := d.Quote(q.Sort.Column), q.Sort.comparison()
+	var preds []string
+	switch {
+	case cursor.IsZero():
+	case q.tiebreaks() && cursor.Tiebreak != nil:
+		// the rows after the cursor row on (sort column, tiebreak column),
+		// expanded for the dialects without row value comparisons
+		tiebreak := d.Quote(q.Tiebreak)
+		preds = append(preds, "("+sortColumn+" "+op+" "+bind(cursor.Value)+" OR ("+
+			sortColumn+" = "+bind(cursor.Value)+" AND "+tiebreak+" "+op+" "+bind(cursor.Tiebreak)+"))")
+	default:
+		preds = append(preds, sortColumn+" "+op+" "+bind(cursor.Value))
+	}
+	for _, c := range append(q.Where[:len(q.Where):len(q.Where)], q.Filters.Conditions()...) {
+		if err := c.validate(); err != nil {
+			return "", nil, err
+		}
+		preds = append(preds, c.render(d, bind))
+	}
+	var sb strings.Builder
+	sb.WriteString("SELECT " + columns + " FROM " + q.From)
+	if len(preds) != 0 {
+		sb.WriteString(" WHERE " + strings.Join(preds, " AND "))
+	}
+	sb.WriteString(" ORDER BY " + sortColumn + " " + string(q.Sort.Order))
+	if q.tiebreaks() {
+		sb.WriteString(", " + d.Quote(q.Tiebreak) + " " + string(q.Sort.Order))
+	}
+	sb.WriteString(" " + d.Limit(bind(q.Limit)))
+	return sb.String(), args, nil
+}
+
+// Queryer runs queries. It is implemented by [sql.DB], [sql.Tx] and
+// [sql.Conn].
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Scanner scans the columns of a row. It is implemented by [sql.Rows] and
+// [sql.Row].
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Mapper maps the rows of a query to values of T.
+type Mapper[T any] struct {
+	// Scan scans the current row, in the order of the query's columns.
+	Scan func(Scanner) (T, error)
+	// Key returns the value of column for v, used as the cursor of the next
+	// page with the value of the tiebreak column. It must not return nil. When
+	// Key is nil, the next cursor is not set.
+	Key func(v T, column string) interface{}
+}
+
+// Fetch runs the query for the page after cursor on db, mapping the rows with m.
+func Fetch[T any](ctx context.Context, db Queryer, d Dialect, q Query, cursor Cursor, m Mapper[T]) (Page[T], error) {
+	query, args, err := q.Build(d, cursor)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	defer rows.Close()
+	var page Page[T]
+	for rows.Next() {
+		v, err := m.Scan(rows)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.Items = append(page.Items, v)
+	}
+	if err := rows.Err(); err != nil {
+		return Page[T]{}, err
+	}
+	// the last row is the cursor of the next page
+	if last, ok := page.Last(); ok && m.Key != nil {
+		page.Next = After(m.Key(last, q.Sort.Column))
+		if q.tiebreaks() {
+			page.Next.Tiebreak = m.Key(last, q.Tiebreak)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,340 @@
+package paginator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestQueryBuild tests the SQL and arguments rendered for each dialect.
+func TestQueryBuild(t *testing.T) {
+	q := Query{
+		From:    "resources",
+		Columns: []string{"id", "name", "created_at", "deleted_at"},
+		Where:   []Condition{IsNull("deleted_at"), Ge("created_at", "2024-09-25")},
+		Sort:    SortKey{Column: "name", Order: Asc},
+		Filters: Filters{"name": []string{"a", "b"}, "id": 7},
+		Limit:   10,
+	}
+
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		cursor   Cursor
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "MySQL with cursor",
+			dialect:  MySQL,
+			cursor:   After("m"),
+			expected: "SELECT `id`, `name`, `created_at`, `deleted_at` FROM resources WHERE `name` > ? AND `deleted_at` IS NULL AND `created_at` >= ? AND `id` = ? AND `name` IN (?, ?) ORDER BY `name` ASC LIMIT ?",
+			args:     []interface{}{"m", "2024-09-25", 7, "a", "b", 10},
+		},
+		{
+			name:     "Postgres with cursor",
+			dialect:  Postgres,
+			cursor:   After("m"),
+			expected: `SELECT "id", "name", "created_at", "deleted_at" FROM resources WHERE "name" > $1 AND "deleted_at" IS NULL AND "created_at" >= $2 AND "id" = $3 AND "name" IN ($4, $5) ORDER BY "name" ASC LIMIT $6`,
+			args:     []interface{}{"m", "2024-09-25", 7, "a", "b", 10},
+		},
+		{
+			name:     "Zero cursor starts from the first row",
+			dialect:  SQLite,
+			cursor:   Cursor{},
+			expected: `SELECT "id", "name", "created_at", "deleted_at" FROM resources WHERE "deleted_at" IS NULL AND "created_at" >= ? AND "id" = ? AND "name" IN (?, ?) ORDER BY "name" ASC LIMIT ?`,
+			args:     []interface{}{"2024-09-25", 7, "a", "b", 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := q.Build(tt.dialect, tt.cursor)
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if query != tt.expected {
+				t.Errorf("Expected query:\n%s\ngot:\n%s", tt.expected, query)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Expected args: %v, got: %v", tt.args, args)
+			}
+		})
+	}
+}
+
+// TestQueryBuildSubquery tests that the arguments of a subquery come first and
+// that only its keys can be sorted on.
+func TestQueryBuildSubquery(t *testing.T) {
+	q := Query{
+		From:     "(SELECT id, name FROM resources WHERE name LIKE $1) AS q",
+		FromArgs: []interface{}{"Resource%"},
+		Columns:  []string{"id", "name"},
+		Keys:     []string{"name"},
+		Sort:     SortKey{Column: "name", Order: Desc},
+		Limit:    2,
+	}
+	query, args, err := q.Build(Postgres, After("z"))
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	expected := `SELECT "id", "name" FROM (SELECT id, name FROM resources WHERE name LIKE $1) AS q WHERE "name" < $2 ORDER BY "name" DESC LIMIT $3`
+	if query != expected {
+		t.Errorf("Expected query:\n%s\ngot:\n%s", expected, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Resource%", "z", 2}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	q.Sort.Column = "id"
+	if _, _, err := q.Build(Postgres, Cursor{}); err == nil {
+		t.Errorf("Expected an error sorting on a column that is not a key")
+	}
+}
+
+// TestQueryBuildTiebreak tests that the rows tying with the cursor on the sort
+// column are ordered and selected by the tiebreak column.
+func TestQueryBuildTiebreak(t *testing.T) {
+	q := Query{
+		From:     "resources",
+		Columns:  []string{"id", "name"},
+		Sort:     SortKey{Column: "name", Order: Desc},
+		Tiebreak: "id",
+		Limit:    10,
+	}
+
+	tests := []struct {
+		name     string
+		cursor   Cursor
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Cursor row",
+			cursor:   AfterRow("m", 7),
+			expected: "SELECT `id`, `name` FROM resources WHERE (`name` < ? OR (`name` = ? AND `id` < ?)) ORDER BY `name` DESC, `id` DESC LIMIT ?",
+			args:     []interface{}{"m", "m", 7, 10},
+		},
+		{
+			name:     "Cursor value",
+			cursor:   After("m"),
+			expected: "SELECT `id`, `name` FROM resources WHERE `name` < ? ORDER BY `name` DESC, `id` DESC LIMIT ?",
+			args:     []interface{}{"m", 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := q.Build(MySQL, tt.cursor)
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if query != tt.expected {
+				t.Errorf("Expected query:\n%s\ngot:\n%s", tt.expected, query)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Expected args: %v, got: %v", tt.args, args)
+			}
+		})
+	}
+
+	// The sort column needs no tiebreak.
+	q.Sort.Column = "id"
+	query, _, err := q.Build(MySQL, AfterRow(7, 7))
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if expected := "SELECT `id`, `name` FROM resources WHERE `id` < ? ORDER BY `id` DESC LIMIT ?"; query != expected {
+		t.Errorf("Expected query:\n%s\ngot:\n%s", expected, query)
+	}
+}
+
+// TestLimitExecutionTime tests the execution time hints of the dialects.
+func TestLimitExecutionTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		query    string
+		timeout  time.Duration
+		expected string
+	}{
+		{"MySQL", MySQL, "SELECT `id` FROM resources", 1500 * time.Millisecond, "SELECT /*+ MAX_EXECUTION_TIME(1500) */ `id` FROM resources"},
+		{"MySQL rounds up to 1ms", MySQL, "SELECT `id` FROM resources", time.Microsecond, "SELECT /*+ MAX_EXECUTION_TIME(1) */ `id` FROM resources"},
+		{"MySQL leaves other statements", MySQL, "UPDATE resources SET name = ?", time.Second, "UPDATE resources SET name = ?"},
+		{"Postgres has no hint", Postgres, `SELECT "id" FROM resources`, time.Second, `SELECT "id" FROM resources`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if query := LimitExecutionTime(tt.dialect, tt.query, tt.timeout); query != tt.expected {
+				t.Errorf("Expected query:\n%s\ngot:\n%s", tt.expected, query)
+			}
+		})
+	}
+}
+
+// TestQueryValidate tests the field errors of invalid queries.
+func TestQueryValidate(t *testing.T) {
+	valid := Query{
+		From:    "resources",
+		Columns: []string{"id", "name"},
+		Sort:    SortKey{Column: "id", Order: Asc},
+		Limit:   1,
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(*Query)
+		field string
+	}{
+		{"Empty column", func(q *Query) { q.Sort.Column = "" }, "column"},
+		{"Unknown column", func(q *Query) { q.Sort.Column = "uuid" }, "column"},
+		{"Injected column", func(q *Query) { q.Columns = nil; q.Sort.Column = "id; DROP TABLE resources" }, "column"},
+		{"Invalid order", func(q *Query) { q.Sort.Order = "INVALID" }, "order"},
+		{"Negative limit", func(q *Query) { q.Limit = -1 }, "limit"},
+		{"Unknown filter", func(q *Query) { q.Filters = Filters{"uuid": "x"} }, "filters"},
+		{"Invalid condition", func(q *Query) { q.Where = []Condition{{Column: "id", Op: "LIKE"}} }, "filters"},
+		{"Unknown condition column", func(q *Query) { q.Where = []Condition{Gt("uuid", "x")} }, "filters"},
+		{"Unknown tiebreak", func(q *Query) { q.Tiebreak = "uuid" }, "column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid
+			tt.edit(&q)
+			_, _, err := q.Build(MySQL, Cursor{})
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Expected a FieldError, got: %v", err)
+			}
+			if fe.Field != tt.field {
+				t.Errorf("Expected field %q, got: %q", tt.field, fe.Field)
+			}
+		})
+	}
+}
+
+type item struct {
+	ID   int
+	Name string
+}
+
+var itemMapper = Mapper[item]{
+	Scan: func(s Scanner) (item, error) {
+		var i item
+		err := s.Scan(&i.ID, &i.Name)
+		return i, err
+	},
+	Key: func(i item, column string) interface{} {
+		if column == "name" {
+			return i.Name
+		}
+		return i.ID
+	},
+}
+
+// TestFetch tests paging through a table until an empty page.
+func TestFetch(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id, name) VALUES (1, 'e'), (2, 'd'), (3, 'c'), (4, 'b'), (5, 'a')`); err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+
+	q := Query{
+		From:    "items",
+		Columns: []string{"id", "name"},
+		Sort:    SortKey{Column: "name", Order: Asc},
+		Limit:   2,
+	}
+	ctx := context.Background()
+	var names []string
+	var cursor Cursor
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("Pagination did not terminate")
+		}
+		page, err := Fetch(ctx, db, SQLite, q, cursor, itemMapper)
+		if err != nil {
+			t.Fatalf("Failed to fetch page: %v", err)
+		}
+		if len(page.Items) == 0 {
+			if !page.Next.IsZero() {
+				t.Errorf("Expected a zero next cursor for an empty page, got: %v", page.Next)
+			}
+			break
+		}
+		for _, i := range page.Items {
+			names = append(names, i.Name)
+		}
+		cursor = page.Next
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("Expected names in order, got: %v", names)
+	}
+}
+
+// TestFetchTies tests paging through rows with equal values of the sort column
+// in both orders, without skipping the rows tying with the last row of a page.
+func TestFetchTies(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id, name) VALUES (1, 'b'), (2, 'a'), (3, 'b'), (4, 'a'), (5, 'b'), (6, 'c')`); err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+
+	tests := []struct {
+		order    Order
+		expected []int
+	}{
+		{Asc, []int{2, 4, 1, 3, 5, 6}},
+		{Desc, []int{6, 5, 3, 1, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			q := Query{
+				From:     "items",
+				Columns:  []string{"id", "name"},
+				Sort:     SortKey{Column: "name", Order: tt.order},
+				Tiebreak: "id",
+				Limit:    2,
+			}
+			var ids []int
+			var cursor Cursor
+			for pages := 0; ; pages++ {
+				if pages > len(tt.expected) {
+					t.Fatalf("Pagination did not terminate")
+				}
+				page, err := Fetch(context.Background(), db, SQLite, q, cursor, itemMapper)
+				if err != nil {
+					t.Fatalf("Failed to fetch page: %v", err)
+				}
+				if len(page.Items) == 0 {
+					break
+				}
+				for _, i := range page.Items {
+					ids = append(ids, i.ID)
+				}
+				cursor = page.Next
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected ids: %v, got: %v", tt.expected, ids)
+			}
+		})
+	}
+}
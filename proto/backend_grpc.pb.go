@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -24,6 +25,18 @@ const _ = grpc.SupportPackageIsVersion7
 type ResourceServiceClient interface {
 	// ListResources RPC for listing resources with pagination.
 	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error)
+	// SyncResources RPC for pulling the resources changed since a watermark.
+	SyncResources(ctx context.Context, in *SyncResourcesRequest, opts ...grpc.CallOption) (*SyncResourcesResponse, error)
+	// StreamResources RPC for streaming every resource matching the filters.
+	StreamResources(ctx context.Context, in *StreamResourcesRequest, opts ...grpc.CallOption) (ResourceService_StreamResourcesClient, error)
+	// GetResource RPC for getting a resource by id or uuid.
+	GetResource(ctx context.Context, in *GetResourceRequest, opts ...grpc.CallOption) (*Resource, error)
+	// CreateResource RPC for creating a resource.
+	CreateResource(ctx context.Context, in *CreateResourceRequest, opts ...grpc.CallOption) (*Resource, error)
+	// UpdateResource RPC for updating the fields of a resource selected by a field mask.
+	UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*Resource, error)
+	// DeleteResource RPC for soft deleting a resource.
+	DeleteResource(ctx context.Context, in *DeleteResourceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type resourceServiceClient struct {
@@ -43,12 +56,101 @@ func (c *resourceServiceClient) ListResources(ctx context.Context, in *ListResou
 	return out, nil
 }
 
+func (c *resourceServiceClient) SyncResources(ctx context.Context, in *SyncResourcesRequest, opts ...grpc.CallOption) (*SyncResourcesResponse, error) {
+	out := new(SyncResourcesResponse)
+	err := c.cc.Invoke(ctx, "/backend.ResourceService/SyncResources", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) StreamResources(ctx context.Context, in *StreamResourcesRequest, opts ...grpc.CallOption) (ResourceService_StreamResourcesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceService_ServiceDesc.Streams[0], "/backend.ResourceService/StreamResources", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceServiceStreamResourcesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ResourceService_StreamResourcesClient interface {
+	Recv() (*StreamResourcesResponse, error)
+	grpc.ClientStream
+}
+
+type resourceServiceStreamResourcesClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceServiceStreamResourcesClient) Recv() (*StreamResourcesResponse, error) {
+	m := new(StreamResourcesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *resourceServiceClient) GetResource(ctx context.Context, in *GetResourceRequest, opts ...grpc.CallOption) (*Resource, error) {
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, "/backend.ResourceService/GetResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) CreateResource(ctx context.Context, in *CreateResourceRequest, opts ...grpc.CallOption) (*Resource, error) {
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, "/backend.ResourceService/CreateResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*Resource, error) {
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, "/backend.ResourceService/UpdateResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) DeleteResource(ctx context.Context, in *DeleteResourceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/backend.ResourceService/DeleteResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ResourceServiceServer is the server API for ResourceService service.
 // All implementations must embed UnimplementedResourceServiceServer
 // for forward compatibility
 type ResourceServiceServer interface {
 	// ListResources RPC for listing resources with pagination.
 	ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error)
+	// SyncResources RPC for pulling the resources changed since a watermark.
+	SyncResources(context.Context, *SyncResourcesRequest) (*SyncResourcesResponse, error)
+	// StreamResources RPC for streaming every resource matching the filters.
+	StreamResources(*StreamResourcesRequest, ResourceService_StreamResourcesServer) error
+	// GetResource RPC for getting a resource by id or uuid.
+	GetResource(context.Context, *GetResourceRequest) (*Resource, error)
+	// CreateResource RPC for creating a resource.
+	CreateResource(context.Context, *CreateResourceRequest) (*Resource, error)
+	// UpdateResource RPC for updating the fields of a resource selected by a field mask.
+	UpdateResource(context.Context, *UpdateResourceRequest) (*Resource, error)
+	// DeleteResource RPC for soft deleting a resource.
+	DeleteResource(context.Context, *DeleteResourceRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedResourceServiceServer()
 }
 
@@ -59,6 +161,24 @@ type UnimplementedResourceServiceServer struct {
 func (UnimplementedResourceServiceServer) ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListResources not implemented")
 }
+func (UnimplementedResourceServiceServer) SyncResources(context.Context, *SyncResourcesRequest) (*SyncResourcesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncResources not implemented")
+}
+func (UnimplementedResourceServiceServer) StreamResources(*StreamResourcesRequest, ResourceService_StreamResourcesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResources not implemented")
+}
+func (UnimplementedResourceServiceServer) GetResource(context.Context, *GetResourceRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResource not implemented")
+}
+func (UnimplementedResourceServiceServer) CreateResource(context.Context, *CreateResourceRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateResource not implemented")
+}
+func (UnimplementedResourceServiceServer) UpdateResource(context.Context, *UpdateResourceRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateResource not implemented")
+}
+func (UnimplementedResourceServiceServer) DeleteResource(context.Context, *DeleteResourceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteResource not implemented")
+}
 func (UnimplementedResourceServiceServer) mustEmbedUnimplementedResourceServiceServer() {}
 
 // UnsafeResourceServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -90,6 +210,117 @@ func _ResourceService_ListResources_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ResourceService_SyncResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncResourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).SyncResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.ResourceService/SyncResources",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).SyncResources(ctx, req.(*SyncResourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_StreamResources_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResourcesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ResourceServiceServer).StreamResources(m, &resourceServiceStreamResourcesServer{stream})
+}
+
+type ResourceService_StreamResourcesServer interface {
+	Send(*StreamResourcesResponse) error
+	grpc.ServerStream
+}
+
+type resourceServiceStreamResourcesServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceServiceStreamResourcesServer) Send(m *StreamResourcesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ResourceService_GetResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).GetResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.ResourceService/GetResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).GetResource(ctx, req.(*GetResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_CreateResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).CreateResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.ResourceService/CreateResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).CreateResource(ctx, req.(*CreateResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_UpdateResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).UpdateResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.ResourceService/UpdateResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).UpdateResource(ctx, req.(*UpdateResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_DeleteResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).DeleteResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.ResourceService/DeleteResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).DeleteResource(ctx, req.(*DeleteResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ResourceService_ServiceDesc is the grpc.ServiceDesc for ResourceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -101,8 +332,34 @@ var ResourceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListResources",
 			Handler:    _ResourceService_ListResources_Handler,
 		},
+		{
+			MethodName: "SyncResources",
+			Handler:    _ResourceService_SyncResources_Handler,
+		},
+		{
+			MethodName: "GetResource",
+			Handler:    _ResourceService_GetResource_Handler,
+		},
+		{
+			MethodName: "CreateResource",
+			Handler:    _ResourceService_CreateResource_Handler,
+		},
+		{
+			MethodName: "UpdateResource",
+			Handler:    _ResourceService_UpdateResource_Handler,
+		},
+		{
+			MethodName: "DeleteResource",
+			Handler:    _ResourceService_DeleteResource_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResources",
+			Handler:       _ResourceService_StreamResources_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "backend.proto",
 }
 
@@ -112,6 +369,16 @@ var ResourceService_ServiceDesc = grpc.ServiceDesc{
 type AnimalRankingServiceClient interface {
 	// ListAnimalRankings RPC for listing animal rankings with pagination.
 	ListAnimalRankings(ctx context.Context, in *ListAnimalRankingsRequest, opts ...grpc.CallOption) (*ListAnimalRankingsResponse, error)
+	// StreamAnimalRankings RPC for streaming every animal ranking matching the filters.
+	StreamAnimalRankings(ctx context.Context, in *StreamAnimalRankingsRequest, opts ...grpc.CallOption) (AnimalRankingService_StreamAnimalRankingsClient, error)
+	// GetAnimalRanking RPC for getting an animal ranking by id or rank.
+	GetAnimalRanking(ctx context.Context, in *GetAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error)
+	// CreateAnimalRanking RPC for creating an animal ranking.
+	CreateAnimalRanking(ctx context.Context, in *CreateAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error)
+	// UpdateAnimalRanking RPC for updating the fields of an animal ranking selected by a field mask.
+	UpdateAnimalRanking(ctx context.Context, in *UpdateAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error)
+	// DeleteAnimalRanking RPC for deleting an animal ranking.
+	DeleteAnimalRanking(ctx context.Context, in *DeleteAnimalRankingRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type animalRankingServiceClient struct {
@@ -131,12 +398,90 @@ func (c *animalRankingServiceClient) ListAnimalRankings(ctx context.Context, in
 	return out, nil
 }
 
+func (c *animalRankingServiceClient) StreamAnimalRankings(ctx context.Context, in *StreamAnimalRankingsRequest, opts ...grpc.CallOption) (AnimalRankingService_StreamAnimalRankingsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AnimalRankingService_ServiceDesc.Streams[0], "/backend.AnimalRankingService/StreamAnimalRankings", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &animalRankingServiceStreamAnimalRankingsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnimalRankingService_StreamAnimalRankingsClient interface {
+	Recv() (*StreamAnimalRankingsResponse, error)
+	grpc.ClientStream
+}
+
+type animalRankingServiceStreamAnimalRankingsClient struct {
+	grpc.ClientStream
+}
+
+func (x *animalRankingServiceStreamAnimalRankingsClient) Recv() (*StreamAnimalRankingsResponse, error) {
+	m := new(StreamAnimalRankingsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *animalRankingServiceClient) GetAnimalRanking(ctx context.Context, in *GetAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error) {
+	out := new(AnimalRanking)
+	err := c.cc.Invoke(ctx, "/backend.AnimalRankingService/GetAnimalRanking", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalRankingServiceClient) CreateAnimalRanking(ctx context.Context, in *CreateAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error) {
+	out := new(AnimalRanking)
+	err := c.cc.Invoke(ctx, "/backend.AnimalRankingService/CreateAnimalRanking", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalRankingServiceClient) UpdateAnimalRanking(ctx context.Context, in *UpdateAnimalRankingRequest, opts ...grpc.CallOption) (*AnimalRanking, error) {
+	out := new(AnimalRanking)
+	err := c.cc.Invoke(ctx, "/backend.AnimalRankingService/UpdateAnimalRanking", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalRankingServiceClient) DeleteAnimalRanking(ctx context.Context, in *DeleteAnimalRankingRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/backend.AnimalRankingService/DeleteAnimalRanking", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AnimalRankingServiceServer is the server API for AnimalRankingService service.
 // All implementations must embed UnimplementedAnimalRankingServiceServer
 // for forward compatibility
 type AnimalRankingServiceServer interface {
 	// ListAnimalRankings RPC for listing animal rankings with pagination.
 	ListAnimalRankings(context.Context, *ListAnimalRankingsRequest) (*ListAnimalRankingsResponse, error)
+	// StreamAnimalRankings RPC for streaming every animal ranking matching the filters.
+	StreamAnimalRankings(*StreamAnimalRankingsRequest, AnimalRankingService_StreamAnimalRankingsServer) error
+	// GetAnimalRanking RPC for getting an animal ranking by id or rank.
+	GetAnimalRanking(context.Context, *GetAnimalRankingRequest) (*AnimalRanking, error)
+	// CreateAnimalRanking RPC for creating an animal ranking.
+	CreateAnimalRanking(context.Context, *CreateAnimalRankingRequest) (*AnimalRanking, error)
+	// UpdateAnimalRanking RPC for updating the fields of an animal ranking selected by a field mask.
+	UpdateAnimalRanking(context.Context, *UpdateAnimalRankingRequest) (*AnimalRanking, error)
+	// DeleteAnimalRanking RPC for deleting an animal ranking.
+	DeleteAnimalRanking(context.Context, *DeleteAnimalRankingRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedAnimalRankingServiceServer()
 }
 
@@ -147,6 +492,21 @@ type UnimplementedAnimalRankingServiceServer struct {
 func (UnimplementedAnimalRankingServiceServer) ListAnimalRankings(context.Context, *ListAnimalRankingsRequest) (*ListAnimalRankingsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListAnimalRankings not implemented")
 }
+func (UnimplementedAnimalRankingServiceServer) StreamAnimalRankings(*StreamAnimalRankingsRequest, AnimalRankingService_StreamAnimalRankingsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAnimalRankings not implemented")
+}
+func (UnimplementedAnimalRankingServiceServer) GetAnimalRanking(context.Context, *GetAnimalRankingRequest) (*AnimalRanking, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnimalRanking not implemented")
+}
+func (UnimplementedAnimalRankingServiceServer) CreateAnimalRanking(context.Context, *CreateAnimalRankingRequest) (*AnimalRanking, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAnimalRanking not implemented")
+}
+func (UnimplementedAnimalRankingServiceServer) UpdateAnimalRanking(context.Context, *UpdateAnimalRankingRequest) (*AnimalRanking, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAnimalRanking not implemented")
+}
+func (UnimplementedAnimalRankingServiceServer) DeleteAnimalRanking(context.Context, *DeleteAnimalRankingRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAnimalRanking not implemented")
+}
 func (UnimplementedAnimalRankingServiceServer) mustEmbedUnimplementedAnimalRankingServiceServer() {}
 
 // UnsafeAnimalRankingServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -178,6 +538,99 @@ func _AnimalRankingService_ListAnimalRankings_Handler(srv interface{}, ctx conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AnimalRankingService_StreamAnimalRankings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAnimalRankingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnimalRankingServiceServer).StreamAnimalRankings(m, &animalRankingServiceStreamAnimalRankingsServer{stream})
+}
+
+type AnimalRankingService_StreamAnimalRankingsServer interface {
+	Send(*StreamAnimalRankingsResponse) error
+	grpc.ServerStream
+}
+
+type animalRankingServiceStreamAnimalRankingsServer struct {
+	grpc.ServerStream
+}
+
+func (x *animalRankingServiceStreamAnimalRankingsServer) Send(m *StreamAnimalRankingsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnimalRankingService_GetAnimalRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnimalRankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalRankingServiceServer).GetAnimalRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.AnimalRankingService/GetAnimalRanking",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalRankingServiceServer).GetAnimalRanking(ctx, req.(*GetAnimalRankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalRankingService_CreateAnimalRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAnimalRankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalRankingServiceServer).CreateAnimalRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.AnimalRankingService/CreateAnimalRanking",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalRankingServiceServer).CreateAnimalRanking(ctx, req.(*CreateAnimalRankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalRankingService_UpdateAnimalRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAnimalRankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalRankingServiceServer).UpdateAnimalRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.AnimalRankingService/UpdateAnimalRanking",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalRankingServiceServer).UpdateAnimalRanking(ctx, req.(*UpdateAnimalRankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalRankingService_DeleteAnimalRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAnimalRankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalRankingServiceServer).DeleteAnimalRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.AnimalRankingService/DeleteAnimalRanking",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalRankingServiceServer).DeleteAnimalRanking(ctx, req.(*DeleteAnimalRankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AnimalRankingService_ServiceDesc is the grpc.ServiceDesc for AnimalRankingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -189,7 +642,185 @@ var AnimalRankingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListAnimalRankings",
 			Handler:    _AnimalRankingService_ListAnimalRankings_Handler,
 		},
+		{
+			MethodName: "GetAnimalRanking",
+			Handler:    _AnimalRankingService_GetAnimalRanking_Handler,
+		},
+		{
+			MethodName: "CreateAnimalRanking",
+			Handler:    _AnimalRankingService_CreateAnimalRanking_Handler,
+		},
+		{
+			MethodName: "UpdateAnimalRanking",
+			Handler:    _AnimalRankingService_UpdateAnimalRanking_Handler,
+		},
+		{
+			MethodName: "DeleteAnimalRanking",
+			Handler:    _AnimalRankingService_DeleteAnimalRanking_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAnimalRankings",
+			Handler:       _AnimalRankingService_StreamAnimalRankings_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "backend.proto",
+}
+
+// CollectionServiceClient is the client API for CollectionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CollectionServiceClient interface {
+	// DescribeCollection RPC for describing the fields, sort keys, filter operators and page sizes of the collections.
+	DescribeCollection(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*DescribeCollectionResponse, error)
+	// Export RPC for streaming the records of a collection matching the filters as CSV or NDJSON chunks.
+	// The gateway serves it as a file download at /v1/collections/{collection}/export.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (CollectionService_ExportClient, error)
+}
+
+type collectionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollectionServiceClient(cc grpc.ClientConnInterface) CollectionServiceClient {
+	return &collectionServiceClient{cc}
+}
+
+func (c *collectionServiceClient) DescribeCollection(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*DescribeCollectionResponse, error) {
+	out := new(DescribeCollectionResponse)
+	err := c.cc.Invoke(ctx, "/backend.CollectionService/DescribeCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collectionServiceClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (CollectionService_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CollectionService_ServiceDesc.Streams[0], "/backend.CollectionService/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &collectionServiceExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CollectionService_ExportClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type collectionServiceExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *collectionServiceExportClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CollectionServiceServer is the server API for CollectionService service.
+// All implementations must embed UnimplementedCollectionServiceServer
+// for forward compatibility
+type CollectionServiceServer interface {
+	// DescribeCollection RPC for describing the fields, sort keys, filter operators and page sizes of the collections.
+	DescribeCollection(context.Context, *DescribeCollectionRequest) (*DescribeCollectionResponse, error)
+	// Export RPC for streaming the records of a collection matching the filters as CSV or NDJSON chunks.
+	// The gateway serves it as a file download at /v1/collections/{collection}/export.
+	Export(*ExportRequest, CollectionService_ExportServer) error
+	mustEmbedUnimplementedCollectionServiceServer()
+}
+
+// UnimplementedCollectionServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCollectionServiceServer struct {
+}
+
+func (UnimplementedCollectionServiceServer) DescribeCollection(context.Context, *DescribeCollectionRequest) (*DescribeCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeCollection not implemented")
+}
+func (UnimplementedCollectionServiceServer) Export(*ExportRequest, CollectionService_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedCollectionServiceServer) mustEmbedUnimplementedCollectionServiceServer() {}
+
+// UnsafeCollectionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CollectionServiceServer will
+// result in compilation errors.
+type UnsafeCollectionServiceServer interface {
+	mustEmbedUnimplementedCollectionServiceServer()
+}
+
+func RegisterCollectionServiceServer(s grpc.ServiceRegistrar, srv CollectionServiceServer) {
+	s.RegisterService(&CollectionService_ServiceDesc, srv)
+}
+
+func _CollectionService_DescribeCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectionServiceServer).DescribeCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.CollectionService/DescribeCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollectionServiceServer).DescribeCollection(ctx, req.(*DescribeCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CollectionService_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CollectionServiceServer).Export(m, &collectionServiceExportServer{stream})
+}
+
+type CollectionService_ExportServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type collectionServiceExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *collectionServiceExportServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CollectionService_ServiceDesc is the grpc.ServiceDesc for CollectionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CollectionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "backend.CollectionService",
+	HandlerType: (*CollectionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DescribeCollection",
+			Handler:    _CollectionService_DescribeCollection_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Export",
+			Handler:       _CollectionService_Export_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "backend.proto",
 }
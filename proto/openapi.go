@@ -0,0 +1,9 @@
+package proto
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI document of the REST/JSON gateway, generated from the
+// google.api.http annotations of backend.proto.
+//
+//go:embed backend.swagger.json
+var OpenAPI []byte
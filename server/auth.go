@@ -0,0 +1,391 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+)
+
+// The roles of the principals.
+const (
+	// roleReader may list, stream, sync, get, describe and export.
+	roleReader = "reader"
+	// roleWriter may also create, update and delete.
+	roleWriter = "writer"
+)
+
+// principal is an authenticated caller.
+type principal struct {
+	// Subject identifies the caller: the name of an API key, the subject of a
+	// JWT or the common name of a client certificate.
+	Subject string
+	// Roles are the roles granted to the caller.
+	Roles []string
+	// Tenant is the tenant of the caller, if any.
+	Tenant string
+	// Source is the authenticator of the caller: "api_key", "jwt" or
+	// "client_cert".
+	Source string
+}
+
+// principalKey is the context key of the principal.
+type principalKey struct{}
+
+// withPrincipal returns a copy of ctx carrying p.
+func withPrincipal(ctx context.Context, p *principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// principalFromContext returns the principal of the RPC of ctx, or false when
+// the call was not authenticated.
+func principalFromContext(ctx context.Context) (*principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*principal)
+	return p, ok
+}
+
+// auditf logs an audited action of the principal of ctx.
+func auditf(ctx context.Context, format string, v ...interface{}) {
+	subject, tenant := "anonymous", ""
+	if p, ok := principalFromContext(ctx); ok {
+		subject, tenant = p.Subject, p.Tenant
+	}
+	slog.Info("audit", "subject", subject, "tenant", tenant, "action", fmt.Sprintf(format, v...))
+}
+
+// errNoCredentials is returned by an authenticator when the request does not
+// carry its kind of credentials, so that the next one is tried.
+var errNoCredentials = errors.New("no credentials")
+
+// authenticator authenticates the callers of the RPCs from the metadata of
+// their requests.
+type authenticator interface {
+	// authenticate returns the principal of md, or errNoCredentials.
+	authenticate(ctx context.Context, md metadata.MD) (*principal, error)
+}
+
+// apiKey is an entry of an API keys file.
+type apiKey struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+	Tenant  string   `json:"tenant"`
+}
+
+// apiKeyAuthenticator authenticates the static API keys of the x-api-key
+// metadata.
+type apiKeyAuthenticator struct {
+	// keys maps the SHA-256 digests of the keys to their principal.
+	keys map[[sha256.Size]byte]*principal
+}
+
+// newAPIKeyAuthenticator returns an authenticator of keys.
+func newAPIKeyAuthenticator(keys []apiKey) *apiKeyAuthenticator {
+	a := &apiKeyAuthenticator{keys: make(map[[sha256.Size]byte]*principal, len(keys))}
+	for _, k := range keys {
+		a.keys[sha256.Sum256([]byte(k.Key))] = &principal{Subject: k.Subject, Roles: k.Roles, Tenant: k.Tenant, Source: "api_key"}
+	}
+	return a
+}
+
+// loadAPIKeys reads a JSON array of API keys from the file at name.
+func loadAPIKeys(name string) ([]apiKey, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var keys []apiKey
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return nil, fmt.Errorf("parse API keys %s: %w", name, err)
+	}
+	for i, k := range keys {
+		if k.Key == "" || k.Subject == "" {
+			return nil, fmt.Errorf("API key %d of %s: key and subject are required", i, name)
+		}
+	}
+	return keys, nil
+}
+
+// authenticate satisfies the authenticator interface.
+func (a *apiKeyAuthenticator) authenticate(_ context.Context, md metadata.MD) (*principal, error) {
+	values := md.Get("x-api-key")
+	if len(values) == 0 {
+		return nil, errNoCredentials
+	}
+	// Compare the digests in constant time, to not leak the keys.
+	digest := sha256.Sum256([]byte(values[0]))
+	for d, p := range a.keys {
+		if subtle.ConstantTimeCompare(d[:], digest[:]) == 1 {
+			return p, nil
+		}
+	}
+	return nil, errors.New("invalid API key")
+}
+
+// jwtAlgorithms are the signature algorithms accepted for JWTs.
+var jwtAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// jwtClaims are the claims of a JWT granting roles.
+type jwtClaims struct {
+	jwt.Claims
+	Roles  []string `json:"roles"`
+	Tenant string   `json:"tenant"`
+}
+
+// jwtAuthenticator authenticates the JWT bearer tokens of the authorization
+// metadata, signed by a key of a local JWKS.
+type jwtAuthenticator struct {
+	keys     jose.JSONWebKeySet
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// newJWTAuthenticator returns an authenticator of the JWTs signed by the keys
+// of the JWKS file at name, issued by issuer for audience when they are set.
+func newJWTAuthenticator(name, issuer, audience string) (*jwtAuthenticator, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	a := &jwtAuthenticator{issuer: issuer, audience: audience, now: time.Now}
+	if err := json.Unmarshal(buf, &a.keys); err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", name, err)
+	}
+	if len(a.keys.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s has no keys", name)
+	}
+	return a, nil
+}
+
+// authenticate satisfies the authenticator interface.
+func (a *jwtAuthenticator) authenticate(_ context.Context, md metadata.MD) (*principal, error) {
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, errNoCredentials
+	}
+	raw, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, errNoCredentials
+	}
+	tok, err := jwt.ParseSigned(raw, jwtAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+	keys := a.keys.Keys
+	if kid := tok.Headers[0].KeyID; kid != "" {
+		keys = a.keys.Key(kid)
+	}
+	for _, key := range keys {
+		var claims jwtClaims
+		if err := tok.Claims(key.Public().Key, &claims); err != nil {
+			continue
+		}
+		expected := jwt.Expected{Issuer: a.issuer, Time: a.now()}
+		if a.audience != "" {
+			expected.AnyAudience = jwt.Audience{a.audience}
+		}
+		if err := claims.ValidateWithLeeway(expected, jwt.DefaultLeeway); err != nil {
+			return nil, fmt.Errorf("validate token: %w", err)
+		}
+		if claims.Subject == "" {
+			return nil, errors.New("token has no subject")
+		}
+		return &principal{Subject: claims.Subject, Roles: claims.Roles, Tenant: claims.Tenant, Source: "jwt"}, nil
+	}
+	return nil, errors.New("token is not signed by a known key")
+}
+
+// certAuthenticator authenticates the client certificates verified by the TLS
+// handshake. The common name of a certificate is the subject, its
+// organizational units are the roles, and its organization is the tenant.
+type certAuthenticator struct{}
+
+// authenticate satisfies the authenticator interface.
+func (certAuthenticator) authenticate(ctx context.Context, _ metadata.MD) (*principal, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errNoCredentials
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, errNoCredentials
+	}
+	cert := info.State.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil, errors.New("client certificate has no common name")
+	}
+	pr := &principal{Subject: cert.Subject.CommonName, Roles: cert.Subject.OrganizationalUnit, Source: "client_cert"}
+	if len(cert.Subject.Organization) > 0 {
+		pr.Tenant = cert.Subject.Organization[0]
+	}
+	return pr, nil
+}
+
+// authzRule grants the methods matching Method, a path.Match pattern of full
+// method names, to the principals with any of Roles.
+type authzRule struct {
+	Method string
+	Roles  []string
+}
+
+// defaultAuthzRules let readers call the read-only RPCs, and writers call
+// every RPC of the services.
+var defaultAuthzRules = []authzRule{
+	{"/backend.*/List*", []string{roleReader, roleWriter}},
+	{"/backend.*/Stream*", []string{roleReader, roleWriter}},
+	{"/backend.*/Sync*", []string{roleReader, roleWriter}},
+	{"/backend.*/Get*", []string{roleReader, roleWriter}},
+	{"/backend.*/Describe*", []string{roleReader, roleWriter}},
+	{"/backend.*/Export*", []string{roleReader, roleWriter}},
+	{"/backend.*/*", []string{roleWriter}},
+}
+
+// publicMethods are the patterns of the methods callable without credentials.
+var publicMethods = []string{
+	"/grpc.health.v1.Health/*",
+	"/grpc.reflection.*/*",
+}
+
+// authorizer authenticates the callers of the RPCs and authorizes their
+// methods.
+type authorizer struct {
+	authenticators []authenticator
+	rules          []authzRule
+	public         []string
+}
+
+// authorize returns a copy of ctx carrying the principal of the call of
+// method, and its tenant as the models tenant, or an Unauthenticated or
+// PermissionDenied status.
+func (a *authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	if matchMethod(a.public, method) {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var p *principal
+	for _, auth := range a.authenticators {
+		var err error
+		p, err = auth.authenticate(ctx, md)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		break
+	}
+	if p == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	// The first rule matching the method applies.
+	for _, rule := range a.rules {
+		if ok, _ := path.Match(rule.Method, method); !ok {
+			continue
+		}
+		for _, role := range p.Roles {
+			if slices.Contains(rule.Roles, role) {
+				ctx = withPrincipal(ctx, p)
+				// Scope the queries of the tenant tables to the caller's tenant.
+				if p.Tenant != "" {
+					ctx = models.WithTenant(ctx, p.Tenant)
+				}
+				return ctx, nil
+			}
+		}
+		break
+	}
+	return nil, status.Errorf(codes.PermissionDenied, "%s may not call %s", p.Subject, method)
+}
+
+// matchMethod reports whether method matches any of patterns.
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unaryInterceptor authorizes unary calls.
+func (a *authorizer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor authorizes streaming calls.
+func (a *authorizer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream is a server stream with the context of an interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context satisfies the grpc.ServerStream interface.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// newAuthorizer returns the authorizer of the API keys of keysFile, the JWTs
+// signed by the keys of jwksFile and, with clientCerts, the verified client
+// certificates, or nil when none is set. The credentials of the requests are
+// preferred to the certificates of the connections.
+func newAuthorizer(keysFile, jwksFile, issuer, audience string, clientCerts bool) (*authorizer, error) {
+	a := &authorizer{rules: defaultAuthzRules, public: publicMethods}
+	if keysFile != "" {
+		keys, err := loadAPIKeys(keysFile)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, newAPIKeyAuthenticator(keys))
+	}
+	if jwksFile != "" {
+		auth, err := newJWTAuthenticator(jwksFile, issuer, audience)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, auth)
+	}
+	if clientCerts {
+		a.authenticators = append(a.authenticators, certAuthenticator{})
+	}
+	if len(a.authenticators) == 0 {
+		return nil, nil
+	}
+	return a, nil
+}
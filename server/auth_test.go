@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+)
+
+// writeJSON writes v to the file name of a temporary directory.
+func writeJSON(t *testing.T, name string, v interface{}) string {
+	t.Helper()
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal %s: %v", name, err)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, buf, 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return file
+}
+
+// signToken returns a JWT of claims signed by key.
+func signToken(t *testing.T, key *ecdsa.PrivateKey, kid string, claims jwtClaims) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", kid))
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+// TestAuthorize tests authenticating API keys and JWTs, authorizing their
+// methods by role and scoping the calls to their tenant.
+func TestAuthorize(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks := writeJSON(t, "jwks.json", jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "key-1", Algorithm: string(jose.ES256), Use: "sig"}}})
+	keys := writeJSON(t, "keys.json", []apiKey{
+		{Key: "reader-key", Subject: "dashboard", Roles: []string{roleReader}},
+		{Key: "writer-key", Subject: "importer", Roles: []string{roleWriter}, Tenant: "acme"},
+	})
+	auth, err := newAuthorizer(keys, jwks, "https://issuer.example", "paginator", false)
+	if err != nil {
+		t.Fatalf("Failed to create authorizer: %v", err)
+	}
+
+	now := time.Now()
+	claims := func(exp time.Time, roles ...string) jwtClaims {
+		return jwtClaims{
+			Claims: jwt.Claims{
+				Subject:  "alice",
+				Issuer:   "https://issuer.example",
+				Audience: jwt.Audience{"paginator"},
+				IssuedAt: jwt.NewNumericDate(now),
+				Expiry:   jwt.NewNumericDate(exp),
+			},
+			Roles:  roles,
+			Tenant: "acme",
+		}
+	}
+	valid := signToken(t, key, "key-1", claims(now.Add(time.Hour), roleReader))
+	expired := signToken(t, key, "key-1", claims(now.Add(-time.Hour), roleReader))
+	unknown := signToken(t, other, "key-1", claims(now.Add(time.Hour), roleWriter))
+
+	const (
+		list   = "/backend.ResourceService/ListResources"
+		stream = "/backend.AnimalRankingService/StreamAnimalRankings"
+		remove = "/backend.ResourceService/DeleteResource"
+		health = "/grpc.health.v1.Health/Check"
+	)
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		method  string
+		code    codes.Code
+		subject string
+		tenant  string
+	}{
+		{"No credentials", nil, list, codes.Unauthenticated, "", ""},
+		{"Public method", nil, health, codes.OK, "", ""},
+		{"Unknown API key", metadata.Pairs("x-api-key", "guess"), list, codes.Unauthenticated, "", ""},
+		{"Reader lists", metadata.Pairs("x-api-key", "reader-key"), list, codes.OK, "dashboard", ""},
+		{"Reader streams", metadata.Pairs("x-api-key", "reader-key"), stream, codes.OK, "dashboard", ""},
+		{"Reader deletes", metadata.Pairs("x-api-key", "reader-key"), remove, codes.PermissionDenied, "", ""},
+		{"Writer deletes", metadata.Pairs("x-api-key", "writer-key"), remove, codes.OK, "importer", "acme"},
+		{"Valid JWT", metadata.Pairs("authorization", "Bearer "+valid), list, codes.OK, "alice", "acme"},
+		{"JWT reader deletes", metadata.Pairs("authorization", "Bearer "+valid), remove, codes.PermissionDenied, "", ""},
+		{"Expired JWT", metadata.Pairs("authorization", "Bearer "+expired), list, codes.Unauthenticated, "", ""},
+		{"JWT of an unknown key", metadata.Pairs("authorization", "Bearer "+unknown), list, codes.Unauthenticated, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			ctx, err := auth.authorize(ctx, tt.method)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("Expected %v, got: %v", tt.code, err)
+			}
+			if tt.subject == "" {
+				return
+			}
+			if p, ok := principalFromContext(ctx); !ok || p.Subject != tt.subject {
+				t.Errorf("Expected principal %q in the context, got: %v", tt.subject, p)
+			}
+			// The generated queries are scoped to the tenant of the principal.
+			tenant, ok := models.TenantFromContext(ctx)
+			if tt.tenant == "" && ok || tt.tenant != "" && tenant != tt.tenant {
+				t.Errorf("Expected tenant %q in the context, got: %v", tt.tenant, tenant)
+			}
+		})
+	}
+}
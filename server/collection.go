@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+	pb "backend/proto"
+)
+
+// CollectionServiceServer is the server implementation for CollectionService.
+type CollectionServiceServer struct {
+	pb.UnimplementedCollectionServiceServer
+	service
+}
+
+// NewCollectionServiceServer returns the CollectionService of the dependencies
+// d.
+func NewCollectionServiceServer(d Deps) *CollectionServiceServer {
+	return &CollectionServiceServer{service: newService(d)}
+}
+
+// DescribeCollection implements the DescribeCollection RPC. The fields and
+// the indexed columns of the collections are generated from the schema with
+// the models, and restricted to what the requests can sort and filter on.
+func (s *CollectionServiceServer) DescribeCollection(ctx context.Context, req *pb.DescribeCollectionRequest) (*pb.DescribeCollectionResponse, error) {
+	collections := []*pb.Collection{
+		s.describe(models.AnimalRankingCollection, sortKeys(models.AnimalRankingCollection, animalRankingSortColumns)),
+		s.describe(models.ResourceCollection, sortKeys(models.ResourceCollection, resourceSortColumns)),
+	}
+	if req.Name == "" {
+		return &pb.DescribeCollectionResponse{Collections: collections}, nil
+	}
+	for _, c := range collections {
+		if c.Name == req.Name {
+			return &pb.DescribeCollectionResponse{Collections: []*pb.Collection{c}}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "collection %q not found", req.Name)
+}
+
+// describe returns the description of the collection c sorted on keys.
+func (s *CollectionServiceServer) describe(c *models.Collection, keys []*pb.SortKey) *pb.Collection {
+	desc := &pb.Collection{
+		Name:     c.Name,
+		SortKeys: keys,
+		PageSizeLimits: &pb.PageSizeLimits{
+			DefaultPageSize:        int32(s.paging.DefaultPageSize),
+			MaxPageSize:            int32(s.paging.MaxPageSize),
+			DefaultStreamBatchSize: int32(s.paging.DefaultStreamBatchSize),
+			MaxStreamBatchSize:     int32(s.paging.MaxStreamBatchSize),
+		},
+	}
+	for _, f := range c.Fields {
+		desc.Fields = append(desc.Fields, &pb.CollectionField{
+			Name:            f.Name,
+			Type:            f.Type,
+			Nullable:        f.Nullable,
+			FilterOperators: filterOperators(f),
+		})
+	}
+	return desc
+}
+
+// sortKeys returns the sort keys of the sort columns of the requests whose
+// columns are indexed in c, ordered by sort column.
+func sortKeys[E interface {
+	~int32
+	fmt.Stringer
+}](c *models.Collection, columns map[E]string) []*pb.SortKey {
+	var values []E
+	for v, column := range columns {
+		if slices.Contains(c.SortKeys, column) {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	keys := make([]*pb.SortKey, len(values))
+	for i, v := range values {
+		keys[i] = &pb.SortKey{Column: columns[v], SortColumn: v.String()}
+	}
+	return keys
+}
+
+// filterOperators returns the operators the requests can filter the field f
+// with: equality and IN with the filters, and the comparisons of the time
+// range filters of its column, as far as the models support them.
+func filterOperators(f models.CollectionField) []string {
+	ops := []string{"=", "IN"}
+	for _, tf := range timeFilters {
+		if tf.column == f.Name && !slices.Contains(ops, tf.op) {
+			ops = append(ops, tf.op)
+		}
+	}
+	return slices.DeleteFunc(ops, func(op string) bool {
+		return !slices.Contains(f.Operators, op)
+	})
+}
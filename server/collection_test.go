@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/config"
+	pb "backend/proto"
+)
+
+// TestDescribeCollection tests the descriptions of the fields, sort keys,
+// filter operators and page sizes of the collections.
+func TestDescribeCollection(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Paging.MaxPageSize = 50
+	s := NewCollectionServiceServer(Deps{Config: cfg})
+
+	resp, err := s.DescribeCollection(ctx, &pb.DescribeCollectionRequest{})
+	if err != nil {
+		t.Fatalf("Failed to describe the collections: %v", err)
+	}
+	var names []string
+	for _, c := range resp.Collections {
+		names = append(names, c.Name)
+	}
+	if !slices.Equal(names, []string{"animal_rankings", "resources"}) {
+		t.Errorf("Expected every collection, got: %v", names)
+	}
+
+	resp, err = s.DescribeCollection(ctx, &pb.DescribeCollectionRequest{Name: "resources"})
+	if err != nil {
+		t.Fatalf("Failed to describe resources: %v", err)
+	}
+	if len(resp.Collections) != 1 {
+		t.Fatalf("Expected the resources collection, got: %v", resp.Collections)
+	}
+	resources := resp.Collections[0]
+	var keys []string
+	for _, k := range resources.SortKeys {
+		keys = append(keys, k.Column+":"+k.SortColumn)
+	}
+	if !slices.Equal(keys, []string{"created_at:RESOURCE_CREATED_AT", "name:RESOURCE_NAME"}) {
+		t.Errorf("Expected the indexed sort columns, got: %v", keys)
+	}
+	if limits := resources.PageSizeLimits; limits.DefaultPageSize != 20 || limits.MaxPageSize != 50 {
+		t.Errorf("Expected the configured page sizes, got: %v", limits)
+	}
+	fields := make(map[string]*pb.CollectionField)
+	for _, f := range resources.Fields {
+		fields[f.Name] = f
+	}
+	for name, want := range map[string][]string{
+		"name":       {"=", "IN"},
+		"created_at": {"=", "IN", ">", "<"},
+		"updated_at": {"=", "IN", ">"},
+	} {
+		if f := fields[name]; f == nil || !slices.Equal(f.FilterOperators, want) {
+			t.Errorf("Expected the operators %v for %s, got: %v", want, name, f)
+		}
+	}
+	if f := fields["deleted_at"]; f == nil || !f.Nullable || f.Type != "timestamp" {
+		t.Errorf("Expected deleted_at to be a nullable timestamp, got: %v", f)
+	}
+
+	_, err = s.DescribeCollection(ctx, &pb.DescribeCollectionRequest{Name: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown collection, got: %v", err)
+	}
+}
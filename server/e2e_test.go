@@ -0,0 +1,532 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "backend/proto"
+)
+
+// seedFile is the schema and sample data of the MySQL database.
+const seedFile = "../db/schema.sql"
+
+// seedTestDB returns an in-memory SQLite database with the sample data of the
+// MySQL database.
+func seedTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := initTestDB(t)
+	seed, err := os.ReadFile(seedFile)
+	if err != nil {
+		t.Fatalf("Failed to read seed: %v", err)
+	}
+	// The tables are created by initTestDB, in SQLite's dialect: only insert
+	// the rows, into the tables of the attached database. SQLite compares the
+	// timestamps as text, so they are written like the driver writes times.
+	into := regexp.MustCompile("INSERT INTO `(\\w+)`")
+	timestamp := regexp.MustCompile(`'(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d)'`)
+	for _, stmt := range strings.Split(string(seed), ";") {
+		i := strings.Index(stmt, "INSERT INTO")
+		if i < 0 {
+			continue
+		}
+		stmt = into.ReplaceAllString(stmt[i:], "INSERT INTO platform.$1")
+		stmt = timestamp.ReplaceAllString(stmt, "'$1+00:00'")
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to seed database: %v", err)
+		}
+	}
+	return db
+}
+
+// e2eClients are the clients of a server started by startE2E.
+type e2eClients struct {
+	resources   pb.ResourceServiceClient
+	rankings    pb.AnimalRankingServiceClient
+	collections pb.CollectionServiceClient
+}
+
+// startE2E starts the server, as configured by New, over an in-memory
+// connection, backed by the seeded database, and returns its clients.
+func startE2E(t *testing.T) e2eClients {
+	t.Helper()
+	srv, err := New(Deps{DB: seedTestDB(t)})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	go srv.grpc.Serve(listener)
+	t.Cleanup(srv.grpc.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return e2eClients{
+		resources:   pb.NewResourceServiceClient(conn),
+		rankings:    pb.NewAnimalRankingServiceClient(conn),
+		collections: pb.NewCollectionServiceClient(conn),
+	}
+}
+
+// resourceNames returns the names of the seeded resources with the numbers.
+func resourceNames(numbers ...int) []string {
+	names := make([]string, len(numbers))
+	for i, n := range numbers {
+		names[i] = "Resource " + strconv.Itoa(n)
+	}
+	return names
+}
+
+// TestE2EListResources tests paging through the seeded resources.
+func TestE2EListResources(t *testing.T) {
+	at := func(hour, min int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(2024, 9, 25, hour, min, 0, 0, time.UTC))
+	}
+	tests := []struct {
+		name     string
+		req      *pb.ListResourcesRequest
+		expected []string
+	}{
+		{
+			name:     "Forward by creation",
+			req:      &pb.ListResourcesRequest{PageSize: 7, Order: pb.SortOrder_ASC},
+			expected: resourceNames(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20),
+		},
+		{
+			name:     "Backward by creation",
+			req:      &pb.ListResourcesRequest{PageSize: 7, Order: pb.SortOrder_DESC},
+			expected: resourceNames(20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1),
+		},
+		{
+			name:     "Forward by name",
+			req:      &pb.ListResourcesRequest{PageSize: 6, SortColumn: pb.ResourceSortColumn_RESOURCE_NAME},
+			expected: resourceNames(1, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 2, 20, 3, 4, 5, 6, 7, 8, 9),
+		},
+		{
+			name:     "Backward by name",
+			req:      &pb.ListResourcesRequest{PageSize: 6, SortColumn: pb.ResourceSortColumn_RESOURCE_NAME, Order: pb.SortOrder_DESC},
+			expected: resourceNames(9, 8, 7, 6, 5, 4, 3, 20, 2, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 1),
+		},
+		{
+			name:     "Filters",
+			req:      &pb.ListResourcesRequest{PageSize: 5, Filters: map[string]string{"uuid": "uuid-3"}},
+			expected: resourceNames(3),
+		},
+		{
+			name:     "Filter expression",
+			req:      &pb.ListResourcesRequest{PageSize: 5, Filter: "name=Resource 12 AND uuid=uuid-12"},
+			expected: resourceNames(12),
+		},
+		{
+			name:     "Creation range",
+			req:      &pb.ListResourcesRequest{PageSize: 2, CreatedAfter: at(11, 2), CreatedBefore: at(11, 17)},
+			expected: resourceNames(14, 15, 16),
+		},
+		{
+			name:     "No match",
+			req:      &pb.ListResourcesRequest{PageSize: 5, Filters: map[string]string{"name": "Resource 21"}},
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startE2E(t)
+			ctx := context.Background()
+			var names []string
+			req := tt.req
+			for pages := 0; ; pages++ {
+				if pages > len(tt.expected) {
+					t.Fatalf("Pagination did not terminate")
+				}
+				resp, err := c.resources.ListResources(ctx, req)
+				if err != nil {
+					t.Fatalf("Failed to list resources: %v", err)
+				}
+				if len(resp.Resources) > int(req.PageSize) {
+					t.Errorf("Expected at most %d resources, got: %d", req.PageSize, len(resp.Resources))
+				}
+				for _, r := range resp.Resources {
+					names = append(names, r.Name)
+				}
+				if resp.NextPageToken == "" {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected names: %v, got: %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+// streamResources returns the names of the resources streamed for req, and
+// the cursors of their batches.
+func streamResources(ctx context.Context, client pb.ResourceServiceClient, req *pb.StreamResourcesRequest) ([]string, []string, error) {
+	stream, err := client.StreamResources(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names, cursors []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return names, cursors, nil
+		}
+		if err != nil {
+			return names, cursors, err
+		}
+		for _, r := range resp.Resources {
+			names = append(names, r.Name)
+		}
+		cursors = append(cursors, resp.Cursor)
+	}
+}
+
+// TestE2EStreamResources tests streaming the seeded resources, and resuming
+// the streams after their first batch.
+func TestE2EStreamResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.StreamResourcesRequest
+		expected []string
+	}{
+		{
+			name:     "Forward by creation",
+			req:      &pb.StreamResourcesRequest{BatchSize: 7},
+			expected: resourceNames(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20),
+		},
+		{
+			name:     "Backward by creation",
+			req:      &pb.StreamResourcesRequest{BatchSize: 7, Order: pb.SortOrder_DESC},
+			expected: resourceNames(20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1),
+		},
+		{
+			name:     "Forward by name",
+			req:      &pb.StreamResourcesRequest{BatchSize: 6, SortColumn: pb.ResourceSortColumn_RESOURCE_NAME},
+			expected: resourceNames(1, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 2, 20, 3, 4, 5, 6, 7, 8, 9),
+		},
+		{
+			name:     "Backward by name",
+			req:      &pb.StreamResourcesRequest{BatchSize: 6, SortColumn: pb.ResourceSortColumn_RESOURCE_NAME, Order: pb.SortOrder_DESC},
+			expected: resourceNames(9, 8, 7, 6, 5, 4, 3, 20, 2, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startE2E(t)
+			ctx := context.Background()
+			names, cursors, err := streamResources(ctx, c.resources, tt.req)
+			if err != nil {
+				t.Fatalf("Failed to stream resources: %v", err)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Fatalf("Expected names: %v, got: %v", tt.expected, names)
+			}
+			tt.req.Cursor = cursors[0]
+			names, _, err = streamResources(ctx, c.resources, tt.req)
+			if err != nil {
+				t.Fatalf("Failed to resume the stream: %v", err)
+			}
+			if expected := tt.expected[tt.req.BatchSize:]; !slices.Equal(names, expected) {
+				t.Errorf("Expected the names after the first batch: %v, got: %v", expected, names)
+			}
+		})
+	}
+}
+
+// cancelingStream is a server stream whose client cancels the call once it
+// receives the first message.
+type cancelingStream[T any] struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	sent   int
+}
+
+// Context satisfies the grpc.ServerStream interface.
+func (s *cancelingStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+// Send counts the messages sent, and cancels the call.
+func (s *cancelingStream[T]) Send(*T) error {
+	s.sent++
+	s.cancel()
+	return nil
+}
+
+// newCancelingStream returns a cancelingStream of messages of type T.
+func newCancelingStream[T any]() *cancelingStream[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cancelingStream[T]{ctx: ctx, cancel: cancel}
+}
+
+// TestStreamCancel tests that the streams of the seeded records stop after the
+// batch the client canceled them on.
+func TestStreamCancel(t *testing.T) {
+	d := Deps{DB: seedTestDB(t)}
+
+	resources := newCancelingStream[pb.StreamResourcesResponse]()
+	err := NewResourceServiceServer(d).StreamResources(&pb.StreamResourcesRequest{BatchSize: 1}, resources)
+	if status.Code(err) != codes.Canceled || resources.sent != 1 {
+		t.Errorf("Expected the resources stream to be canceled after 1 batch, got: %v after %d", err, resources.sent)
+	}
+
+	rankings := newCancelingStream[pb.StreamAnimalRankingsResponse]()
+	err = NewAnimalRankingServiceServer(d).StreamAnimalRankings(&pb.StreamAnimalRankingsRequest{BatchSize: 1}, rankings)
+	if status.Code(err) != codes.Canceled || rankings.sent != 1 {
+		t.Errorf("Expected the animal rankings stream to be canceled after 1 batch, got: %v after %d", err, rankings.sent)
+	}
+}
+
+// TestE2EListAnimalRankings tests paging through the seeded animal rankings.
+func TestE2EListAnimalRankings(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.ListAnimalRankingsRequest
+		expected []int32
+	}{
+		{
+			name:     "Forward by rank",
+			req:      &pb.ListAnimalRankingsRequest{Limit: 8, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_RANK},
+			expected: []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+		},
+		{
+			name:     "Backward by rank",
+			req:      &pb.ListAnimalRankingsRequest{Limit: 8, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_RANK, Order: pb.SortOrder_DESC},
+			expected: []int32{20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		},
+		{
+			name:     "Filters",
+			req:      &pb.ListAnimalRankingsRequest{Limit: 4, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME, Filters: map[string]string{"name": "Koala"}},
+			expected: []int32{16},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startE2E(t)
+			ctx := context.Background()
+			var ranks []int32
+			req := tt.req
+			for pages := 0; ; pages++ {
+				if pages > len(tt.expected) {
+					t.Fatalf("Pagination did not terminate")
+				}
+				resp, err := c.rankings.ListAnimalRankings(ctx, req)
+				if err != nil {
+					t.Fatalf("Failed to list animal rankings: %v", err)
+				}
+				for _, ar := range resp.AnimalRankings {
+					ranks = append(ranks, ar.Rank)
+				}
+				if resp.NextPageToken == "" {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+			if !slices.Equal(ranks, tt.expected) {
+				t.Errorf("Expected ranks: %v, got: %v", tt.expected, ranks)
+			}
+		})
+	}
+}
+
+// streamAnimalRankings returns the ranks of the animal rankings streamed for
+// req, and the cursors of their batches.
+func streamAnimalRankings(ctx context.Context, client pb.AnimalRankingServiceClient, req *pb.StreamAnimalRankingsRequest) ([]int32, []string, error) {
+	stream, err := client.StreamAnimalRankings(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ranks []int32
+	var cursors []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return ranks, cursors, nil
+		}
+		if err != nil {
+			return ranks, cursors, err
+		}
+		for _, ar := range resp.AnimalRankings {
+			ranks = append(ranks, ar.Rank)
+		}
+		cursors = append(cursors, resp.Cursor)
+	}
+}
+
+// TestE2EListAnimalRankingsKey tests paging by rank with the deprecated keys of
+// the clients predating the page tokens.
+func TestE2EListAnimalRankingsKey(t *testing.T) {
+	c := startE2E(t)
+	ctx := context.Background()
+	first, err := c.rankings.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{Limit: 5})
+	if err != nil {
+		t.Fatalf("Failed to list animal rankings: %v", err)
+	}
+	if first.NextKey != 5 {
+		t.Fatalf("Expected the next key 5, got: %d", first.NextKey)
+	}
+	next, err := c.rankings.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{Limit: 5, Key: first.NextKey})
+	if err != nil {
+		t.Fatalf("Failed to list animal rankings after key %d: %v", first.NextKey, err)
+	}
+	var ranks []int32
+	for _, ar := range next.AnimalRankings {
+		ranks = append(ranks, ar.Rank)
+	}
+	if !slices.Equal(ranks, []int32{6, 7, 8, 9, 10}) {
+		t.Errorf("Expected the ranks after 5, got: %v", ranks)
+	}
+
+	_, err = c.rankings.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{Key: 5, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a rank key paging by name, got: %v", err)
+	}
+}
+
+// TestE2EStreamAnimalRankings tests streaming the seeded animal rankings, and
+// resuming the streams after their first batch.
+func TestE2EStreamAnimalRankings(t *testing.T) {
+	byName := []int32{7, 12, 19, 3, 20, 6, 8, 11, 13, 15, 16, 4, 1, 18, 14, 17, 10, 2, 5, 9}
+	byNameDesc := slices.Clone(byName)
+	slices.Reverse(byNameDesc)
+	tests := []struct {
+		name     string
+		req      *pb.StreamAnimalRankingsRequest
+		expected []int32
+	}{
+		{
+			name:     "Forward by rank",
+			req:      &pb.StreamAnimalRankingsRequest{BatchSize: 8},
+			expected: []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+		},
+		{
+			name:     "Backward by rank",
+			req:      &pb.StreamAnimalRankingsRequest{BatchSize: 8, Order: pb.SortOrder_DESC},
+			expected: []int32{20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		},
+		{
+			name:     "Forward by name",
+			req:      &pb.StreamAnimalRankingsRequest{BatchSize: 8, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME},
+			expected: byName,
+		},
+		{
+			name:     "Backward by name",
+			req:      &pb.StreamAnimalRankingsRequest{BatchSize: 8, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME, Order: pb.SortOrder_DESC},
+			expected: byNameDesc,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startE2E(t)
+			ctx := context.Background()
+			ranks, cursors, err := streamAnimalRankings(ctx, c.rankings, tt.req)
+			if err != nil {
+				t.Fatalf("Failed to stream animal rankings: %v", err)
+			}
+			if !slices.Equal(ranks, tt.expected) {
+				t.Fatalf("Expected ranks: %v, got: %v", tt.expected, ranks)
+			}
+			tt.req.Cursor = cursors[0]
+			ranks, _, err = streamAnimalRankings(ctx, c.rankings, tt.req)
+			if err != nil {
+				t.Fatalf("Failed to resume the stream: %v", err)
+			}
+			if expected := tt.expected[tt.req.BatchSize:]; !slices.Equal(ranks, expected) {
+				t.Errorf("Expected the ranks after the first batch: %v, got: %v", expected, ranks)
+			}
+		})
+	}
+}
+
+// TestE2EErrors tests the status codes of invalid and failing calls.
+func TestE2EErrors(t *testing.T) {
+	c := startE2E(t)
+	ctx := context.Background()
+	first, err := c.resources.ListResources(ctx, &pb.ListResourcesRequest{PageSize: 5})
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected codes.Code
+	}{
+		{"Malformed page token", func() error {
+			_, err := c.resources.ListResources(ctx, &pb.ListResourcesRequest{PageToken: "not a token"})
+			return err
+		}, codes.InvalidArgument},
+		{"Page token of another column", func() error {
+			_, err := c.resources.ListResources(ctx, &pb.ListResourcesRequest{SortColumn: pb.ResourceSortColumn_RESOURCE_NAME, PageToken: first.NextPageToken})
+			return err
+		}, codes.InvalidArgument},
+		{"Unknown filter column", func() error {
+			_, err := c.resources.ListResources(ctx, &pb.ListResourcesRequest{Filter: "color=red"})
+			return err
+		}, codes.InvalidArgument},
+		{"Malformed filter", func() error {
+			_, err := c.resources.ListResources(ctx, &pb.ListResourcesRequest{Filter: "name"})
+			return err
+		}, codes.InvalidArgument},
+		{"Invalid timestamp", func() error {
+			_, err := c.rankings.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{UpdatedAfter: &timestamppb.Timestamp{Nanos: -1}})
+			return err
+		}, codes.InvalidArgument},
+		{"Missing name", func() error {
+			_, err := c.resources.CreateResource(ctx, &pb.CreateResourceRequest{Resource: &pb.Resource{}})
+			return err
+		}, codes.InvalidArgument},
+		{"Unknown update mask field", func() error {
+			_, err := c.resources.UpdateResource(ctx, &pb.UpdateResourceRequest{
+				Resource:   &pb.Resource{Id: 1, Name: "Renamed"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"color"}},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"Update unknown resource", func() error {
+			_, err := c.resources.UpdateResource(ctx, &pb.UpdateResourceRequest{Resource: &pb.Resource{Id: 21, Name: "Renamed"}})
+			return err
+		}, codes.NotFound},
+		{"Unknown resource", func() error {
+			_, err := c.resources.GetResource(ctx, &pb.GetResourceRequest{Lookup: &pb.GetResourceRequest_Uuid{Uuid: "uuid-21"}})
+			return err
+		}, codes.NotFound},
+		{"Delete unknown resource", func() error {
+			_, err := c.resources.DeleteResource(ctx, &pb.DeleteResourceRequest{Id: 21})
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.expected {
+				t.Errorf("Expected %v, got: %v", tt.expected, code)
+			}
+		})
+	}
+}
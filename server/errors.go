@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+	"backend/paginator"
+)
+
+// MySQL error numbers.
+const (
+	// mysqlDuplicateEntry is the error of a duplicate key.
+	mysqlDuplicateEntry = 1062
+	// mysqlQueryTimeout is the error of a SELECT aborted by its
+	// MAX_EXECUTION_TIME.
+	mysqlQueryTimeout = 3024
+)
+
+// requestFields maps the fields of a paginator.FieldError to the request
+// fields they come from.
+var requestFields = map[string]string{
+	"column":  "sort_column",
+	"order":   "order",
+	"limit":   "limit",
+	"filters": "filters",
+}
+
+// invalidArgument returns an InvalidArgument status with a BadRequest detail
+// reporting the violation of field.
+func invalidArgument(field, description string) error {
+	st := status.New(codes.InvalidArgument, "invalid "+field+": "+description)
+	detailed, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: description,
+		}},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// statusError translates the errors of the models, the paginator and the
+// database driver to status errors. Status errors are returned unchanged, and
+// unexpected errors are logged and hidden behind an Internal status.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var fieldErr *paginator.FieldError
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &fieldErr):
+		field, ok := requestFields[fieldErr.Field]
+		if !ok {
+			field = fieldErr.Field
+		}
+		return invalidArgument(field, fieldErr.Description)
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, models.ErrDoesNotExist):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, models.ErrAlreadyExists),
+		errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry:
+		return status.Error(codes.AlreadyExists, "already exists")
+	case errors.Is(err, models.ErrMarkedForDeletion):
+		return status.Error(codes.FailedPrecondition, "marked for deletion")
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlQueryTimeout:
+		return status.Error(codes.DeadlineExceeded, "query timeout exceeded")
+	}
+	slog.Error("Internal error", "err", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// errorUnaryInterceptor translates the errors returned by unary handlers with
+// statusError.
+func errorUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, statusError(err)
+}
+
+// errorStreamInterceptor translates the errors returned by stream handlers
+// with statusError.
+func errorStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return statusError(handler(srv, ss))
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+	"backend/paginator"
+)
+
+// TestStatusError tests the status codes model and driver errors translate to.
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"Invalid column", &paginator.FieldError{Field: "column", Description: "cannot sort by \"uuid\""}, codes.InvalidArgument},
+		{"No rows", fmt.Errorf("lookup: %w", sql.ErrNoRows), codes.NotFound},
+		{"Duplicate entry", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'uuid-1' for key 'uuid'"}, codes.AlreadyExists},
+		{"Marked for deletion", &models.ErrUpdateFailed{Err: models.ErrMarkedForDeletion}, codes.FailedPrecondition},
+		{"Timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{"Execution time exceeded", &mysql.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"}, codes.DeadlineExceeded},
+		{"Status", status.Error(codes.PermissionDenied, "denied"), codes.PermissionDenied},
+		{"Other", errors.New("connection refused"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(statusError(tt.err)); code != tt.code {
+				t.Errorf("Expected %v, got: %v", tt.code, code)
+			}
+		})
+	}
+
+	if statusError(nil) != nil {
+		t.Errorf("Expected no error for a nil error")
+	}
+}
+
+// TestStatusErrorFieldViolation tests that paginator field errors report the
+// request field as a BadRequest field violation.
+func TestStatusErrorFieldViolation(t *testing.T) {
+	st := status.Convert(statusError(&paginator.FieldError{Field: "column", Description: "cannot sort by \"uuid\""}))
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			v := br.GetFieldViolations()
+			if len(v) != 1 || v[0].Field != "sort_column" {
+				t.Errorf("Expected a sort_column field violation, got: %v", v)
+			}
+			return
+		}
+	}
+	t.Errorf("Expected a BadRequest detail, got: %v", st.Details())
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/models"
+	"backend/paginator"
+	pb "backend/proto"
+)
+
+// defaultExportSortColumn is the column exports are sorted by when the
+// request does not set one: the primary key, which also orders the records
+// with equal values of the other sort columns.
+const defaultExportSortColumn = "id"
+
+// exportPage returns a keyset page of the records of a collection.
+type exportPage func(ctx context.Context, db models.DB, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]interface{}, error)
+
+// keysetPage returns the exportPage of the KeysetPage function of a model.
+func keysetPage[T any](page func(context.Context, models.DB, string, interface{}, int, string, map[string]interface{}, ...paginator.Condition) ([]T, T, error)) exportPage {
+	return func(ctx context.Context, db models.DB, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]interface{}, error) {
+		items, _, err := page(ctx, db, column, key, limit, order, filters, where...)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]interface{}, len(items))
+		for i, item := range items {
+			records[i] = item
+		}
+		return records, nil
+	}
+}
+
+// exportSource is a collection that can be exported, with its pages.
+type exportSource struct {
+	collection *models.Collection
+	page       exportPage
+}
+
+// exportSources are the collections that can be exported.
+var exportSources = []exportSource{
+	{models.AnimalRankingCollection, keysetPage(models.AnimalRankingKeysetPage)},
+	{models.ResourceCollection, keysetPage(models.ResourceKeysetPage)},
+}
+
+// Export implements the Export RPC. It walks the keyset pages of the sort
+// column, like the stream RPCs, and sends the records of each page as a chunk
+// of CSV or NDJSON lines with the cursor to resume after it.
+func (s *CollectionServiceServer) Export(req *pb.ExportRequest, stream pb.CollectionService_ExportServer) error {
+	ctx := stream.Context()
+	i := slices.IndexFunc(exportSources, func(src exportSource) bool {
+		return src.collection.Name == req.Collection
+	})
+	if i < 0 {
+		return status.Errorf(codes.NotFound, "collection %q not found", req.Collection)
+	}
+	collection, page := exportSources[i].collection, exportSources[i].page
+
+	columns := req.Columns
+	if len(columns) == 0 {
+		for _, f := range collection.Fields {
+			columns = append(columns, f.Name)
+		}
+	}
+	for _, c := range columns {
+		if _, ok := collection.Field(c); !ok {
+			return invalidArgument("columns", fmt.Sprintf("%q is not a field of %s", c, collection.Name))
+		}
+	}
+	column := cmp.Or(req.SortColumn, defaultExportSortColumn)
+	if !slices.Contains(collection.SortKeys, column) {
+		return invalidArgument("sort_column", fmt.Sprintf("cannot sort by %q, sort by one of %s", column, strings.Join(collection.SortKeys, ", ")))
+	}
+	w, err := newExportWriter(req.Format, columns)
+	if err != nil {
+		return err
+	}
+	filters := convertStringMapToInterfaceMap(req.GetFilters())
+	if err := parseFilter(req.Filter, filters); err != nil {
+		return err
+	}
+	where, err := timeConditions(req)
+	if err != nil {
+		return err
+	}
+	key, err := decodePageToken(s.cursors, "cursor", req.Cursor, column, req.Order)
+	if err != nil {
+		return err
+	}
+	batchSize := s.paging.StreamBatchSize(int(req.BatchSize))
+
+	// A resumed export continues the file of the previous one, which has the
+	// header already.
+	cursor := req.Cursor
+	if cursor == "" {
+		w.header()
+	}
+	for {
+		// Stop as soon as the client cancels or disconnects.
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		// Bound each batch by the query timeout, as the export may be long.
+		qctx, cancel := withQueryTimeout(ctx, s.queryTimeout)
+		records, err := page(qctx, s.db, column, key, batchSize, req.Order.String(), filters, where...)
+		cancel()
+		if err != nil {
+			return deadlineError(qctx, err)
+		}
+		observePage("Export", batchSize, len(records))
+		var values map[string]interface{}
+		for _, r := range records {
+			values = recordValues(r)
+			if err := w.write(values); err != nil {
+				return err
+			}
+		}
+		if len(records) > 0 {
+			id, _ := values["id"].(int)
+			key = paginator.AfterRow(values[column], id)
+			if cursor, err = encodePageToken(s.cursors, column, req.Order, values[column], id); err != nil {
+				return err
+			}
+		}
+		// The header is sent even when no record matches.
+		if data := w.chunk(); len(data) > 0 {
+			if err := stream.Send(&pb.ExportChunk{Data: data, Cursor: cursor}); err != nil {
+				return err
+			}
+		}
+		if len(records) < batchSize {
+			return nil
+		}
+	}
+}
+
+// recordValues returns the values of the fields of a model record by column,
+// the names of their json tags. The values of the nullable fields are their
+// driver values, nil when null.
+func recordValues(record interface{}) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	values := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		column, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || column == "" || column == "-" {
+			continue
+		}
+		value := v.Field(i).Interface()
+		if valuer, ok := value.(driver.Valuer); ok {
+			value, _ = valuer.Value()
+		}
+		values[column] = value
+	}
+	return values
+}
+
+// exportWriter encodes the selected columns of the records of an export in
+// its format, buffering them until the chunk is sent.
+type exportWriter struct {
+	columns []string
+	buf     bytes.Buffer
+	csv     *csv.Writer
+}
+
+// newExportWriter returns the writer of columns in format.
+func newExportWriter(format pb.ExportFormat, columns []string) (*exportWriter, error) {
+	w := &exportWriter{columns: columns}
+	switch format {
+	case pb.ExportFormat_CSV:
+		w.csv = csv.NewWriter(&w.buf)
+	case pb.ExportFormat_NDJSON:
+	default:
+		return nil, invalidArgument("format", fmt.Sprintf("unknown format %v", format))
+	}
+	return w, nil
+}
+
+// header writes the header row of a CSV export.
+func (w *exportWriter) header() {
+	if w.csv != nil {
+		w.csv.Write(w.columns)
+	}
+}
+
+// write writes the line of the record of values.
+func (w *exportWriter) write(values map[string]interface{}) error {
+	if w.csv != nil {
+		row := make([]string, len(w.columns))
+		for i, c := range w.columns {
+			if v := exportValue(values[c]); v != nil {
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		return w.csv.Write(row)
+	}
+	// Marshal the fields in column order, which a map would not keep.
+	w.buf.WriteByte('{')
+	for i, c := range w.columns {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(c)
+		value, err := json.Marshal(exportValue(values[c]))
+		if err != nil {
+			return err
+		}
+		w.buf.Write(name)
+		w.buf.WriteByte(':')
+		w.buf.Write(value)
+	}
+	w.buf.WriteString("}\n")
+	return nil
+}
+
+// chunk returns the lines written since the previous chunk.
+func (w *exportWriter) chunk() []byte {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+	data := bytes.Clone(w.buf.Bytes())
+	w.buf.Reset()
+	return data
+}
+
+// exportValue returns the exported value of v: timestamps are exported in
+// UTC, in RFC 3339 format.
+func exportValue(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
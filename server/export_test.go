@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "backend/proto"
+)
+
+// export returns the chunks of the export of req.
+func export(ctx context.Context, client pb.CollectionServiceClient, req *pb.ExportRequest) ([]*pb.ExportChunk, error) {
+	stream, err := client.Export(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []*pb.ExportChunk
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// exportData returns the data of the chunks.
+func exportData(chunks []*pb.ExportChunk) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		sb.Write(c.Data)
+	}
+	return sb.String()
+}
+
+// TestE2EExport tests exporting the seeded collections as CSV and NDJSON.
+func TestE2EExport(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.ExportRequest
+		chunks   int
+		expected string
+	}{
+		{
+			name: "CSV of the selected columns",
+			req: &pb.ExportRequest{
+				Collection:    "animal_rankings",
+				Columns:       []string{"rank", "name"},
+				SortColumn:    "rank",
+				Order:         pb.SortOrder_DESC,
+				CreatedBefore: timestamppb.New(time.Date(2024, 9, 25, 10, 20, 0, 0, time.UTC)),
+				BatchSize:     3,
+			},
+			chunks:   2,
+			expected: "rank,name\n4,Leopard\n3,Elephant\n2,Tiger\n1,Lion\n",
+		},
+		{
+			name:     "CSV of every column",
+			req:      &pb.ExportRequest{Collection: "resources", Filters: map[string]string{"uuid": "uuid-1"}},
+			chunks:   1,
+			expected: "id,uuid,name,created_at,updated_at,deleted_at\n1,uuid-1,Resource 1,2024-09-25T10:00:00Z,2024-09-25T10:00:00Z,\n",
+		},
+		{
+			name: "NDJSON",
+			req: &pb.ExportRequest{
+				Collection: "resources",
+				Format:     pb.ExportFormat_NDJSON,
+				Columns:    []string{"uuid", "created_at"},
+				Filter:     `name="Resource 2" AND name="Resource 3"`,
+			},
+			chunks:   1,
+			expected: "{\"uuid\":\"uuid-2\",\"created_at\":\"2024-09-25T10:05:00Z\"}\n{\"uuid\":\"uuid-3\",\"created_at\":\"2024-09-25T10:10:00Z\"}\n",
+		},
+		{
+			name:     "CSV header without records",
+			req:      &pb.ExportRequest{Collection: "resources", Columns: []string{"name"}, Filter: "name=none"},
+			chunks:   1,
+			expected: "name\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startE2E(t)
+			chunks, err := export(context.Background(), c.collections, tt.req)
+			if err != nil {
+				t.Fatalf("Failed to export: %v", err)
+			}
+			if len(chunks) != tt.chunks {
+				t.Errorf("Expected %d chunks, got: %d", tt.chunks, len(chunks))
+			}
+			if got := exportData(chunks); got != tt.expected {
+				t.Errorf("Expected export:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestE2EExportResume tests resuming an export after each of its chunks.
+func TestE2EExportResume(t *testing.T) {
+	c := startE2E(t)
+	ctx := context.Background()
+	req := &pb.ExportRequest{Collection: "animal_rankings", Columns: []string{"rank"}, SortColumn: "name", BatchSize: 8}
+	chunks, err := export(ctx, c.collections, req)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if len(chunks) != 3 || strings.Count(exportData(chunks), "\n") != 21 {
+		t.Fatalf("Expected the header and 20 records in 3 chunks, got: %q", exportData(chunks))
+	}
+	for i, name := range []string{"first", "second"} {
+		req.Cursor = chunks[i].Cursor
+		resumed, err := export(ctx, c.collections, req)
+		if err != nil {
+			t.Fatalf("Failed to resume the export after the %s chunk: %v", name, err)
+		}
+		if got, expected := exportData(resumed), exportData(chunks[i+1:]); got != expected {
+			t.Errorf("Expected the rest of the export after the %s chunk:\n%s\ngot:\n%s", name, expected, got)
+		}
+	}
+}
+
+// TestE2EExportErrors tests the status codes of invalid exports.
+func TestE2EExportErrors(t *testing.T) {
+	c := startE2E(t)
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		req      *pb.ExportRequest
+		expected codes.Code
+	}{
+		{"Unknown collection", &pb.ExportRequest{Collection: "unknown"}, codes.NotFound},
+		{"Unknown column", &pb.ExportRequest{Collection: "resources", Columns: []string{"color"}}, codes.InvalidArgument},
+		{"Unindexed sort column", &pb.ExportRequest{Collection: "animal_rankings", SortColumn: "created_at"}, codes.InvalidArgument},
+		{"Malformed cursor", &pb.ExportRequest{Collection: "resources", Cursor: "not a cursor"}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := export(ctx, c.collections, tt.req); status.Code(err) != tt.expected {
+				t.Errorf("Expected %v, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestGatewayExport tests downloading an export over HTTP.
+func TestGatewayExport(t *testing.T) {
+	srv := startGateway(t, seedTestDB(t))
+	query := url.Values{"columns": {"uuid", "name"}, "filter": {`name="Resource 2"`}}
+	resp, err := http.Get(srv.URL + "/v1/collections/resources/export?" + query.Encode())
+	if err != nil {
+		t.Fatalf("Failed to download the export: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read the export: %v", err)
+	}
+	if got := string(body); got != "uuid,name\nuuid-2,Resource 2\n" {
+		t.Errorf("Expected the CSV of resource 2, got: %q", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="resources.csv"` {
+		t.Errorf("Expected a CSV attachment, got: %s", got)
+	}
+	if resp.Trailer.Get("Export-Status") != "OK" || resp.Trailer.Get("Export-Cursor") == "" {
+		t.Errorf("Expected the status and cursor trailers, got: %v", resp.Trailer)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/collections/resources/export?columns=color")
+	if err != nil {
+		t.Fatalf("Failed to download the export: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown column, got: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "backend/proto"
+)
+
+// newGateway returns the HTTP handler transcoding the REST/JSON requests under
+// /v1/ to the gRPC server at addr, so they run through the same handlers and
+// interceptors as gRPC calls. It also serves the exports as file downloads,
+// and the OpenAPI document at /openapi.json. The connection to the server,
+// secured by creds, is closed when ctx is done.
+func newGateway(ctx context.Context, addr string, creds credentials.TransportCredentials) (http.Handler, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	gw := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(gatewayHeader), runtime.WithMetadata(gatewayClientAddr))
+	if err := pb.RegisterResourceServiceHandler(ctx, gw, conn); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterAnimalRankingServiceHandler(ctx, gw, conn); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterCollectionServiceHandler(ctx, gw, conn); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gw)
+	mux.Handle("GET "+exportPattern, exportDownload(gw, pb.NewCollectionServiceClient(conn)))
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(pb.OpenAPI)
+	})
+	return mux, nil
+}
+
+// exportPattern is the path of the export downloads.
+const exportPattern = "/v1/collections/{collection}/export"
+
+// exportDownload returns the handler downloading the export of a collection as
+// a CSV or NDJSON file. The query parameters are the fields of the
+// ExportRequest, as for the transcoded requests. The cursor of the last chunk
+// written and the status code of the export are sent in the Export-Cursor and
+// Export-Status trailers, so that a failed download can be resumed with the
+// cursor parameter.
+func exportDownload(gw *runtime.ServeMux, client pb.CollectionServiceClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, outbound := runtime.MarshalerForRequest(gw, r)
+		req := &pb.ExportRequest{}
+		if err := runtime.PopulateQueryParameters(req, r.URL.Query(), utilities.NewDoubleArray(nil)); err != nil {
+			runtime.HTTPError(r.Context(), gw, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		req.Collection = r.PathValue("collection")
+		ctx, err := runtime.AnnotateContext(r.Context(), gw, r, "/backend.CollectionService/Export", runtime.WithHTTPPathPattern(exportPattern))
+		if err != nil {
+			runtime.HTTPError(r.Context(), gw, outbound, w, r, err)
+			return
+		}
+		stream, err := client.Export(ctx, req)
+		if err != nil {
+			runtime.HTTPError(ctx, gw, outbound, w, r, err)
+			return
+		}
+		// Report the errors of the request, before the first chunk, with their
+		// HTTP status.
+		chunk, err := stream.Recv()
+		if err != nil && err != io.EOF {
+			runtime.HTTPError(ctx, gw, outbound, w, r, err)
+			return
+		}
+
+		contentType, ext := "text/csv", "csv"
+		if req.Format == pb.ExportFormat_NDJSON {
+			contentType, ext = "application/x-ndjson", "ndjson"
+		}
+		h := w.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", req.Collection+"."+ext))
+		h.Set("Trailer", "Export-Cursor, Export-Status")
+		rc := http.NewResponseController(w)
+		cursor := req.Cursor
+		for ; err == nil; chunk, err = stream.Recv() {
+			if _, err := w.Write(chunk.Data); err != nil {
+				return
+			}
+			rc.Flush()
+			cursor = chunk.Cursor
+		}
+		code := codes.OK
+		if err != io.EOF {
+			code = status.Code(err)
+		}
+		h.Set("Export-Cursor", cursor)
+		h.Set("Export-Status", code.String())
+	})
+}
+
+// gatewayHeader forwards the API key header to the gRPC server, along with the
+// headers forwarded by default, such as Authorization. The client address
+// metadata is only set by the gateway.
+func gatewayHeader(key string) (string, bool) {
+	if strings.EqualFold(key, "X-Api-Key") {
+		return "x-api-key", true
+	}
+	if strings.EqualFold(key, runtime.MetadataHeaderPrefix+clientAddrMetadata) {
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// clientAddrMetadata is the metadata carrying the host of the address of the
+// HTTP client of a request of the gateway.
+const clientAddrMetadata = "x-gateway-client-addr"
+
+// gatewayClientAddr forwards the host of the address of the HTTP client of req
+// to the gRPC server, which sees the gateway as the peer of every request.
+func gatewayClientAddr(_ context.Context, req *http.Request) metadata.MD {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+	return metadata.Pairs(clientAddrMetadata, host)
+}
+
+// pinnedCredentials returns the credentials of the gateway connecting to the
+// gRPC server serving the certificate returned by current, which may change
+// when it is reloaded. The gateway dials the server by its loopback address,
+// which the certificate need not name, so instead of verifying the chain and
+// host name it accepts only the certificate itself. The gateway presents no
+// client certificate, which is why mutual TLS requires other credentials.
+func pinnedCredentials(current func() *tls.Certificate) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert := current()
+			if len(rawCerts) == 0 || len(cert.Certificate) == 0 || !bytes.Equal(rawCerts[0], cert.Certificate[0]) {
+				return errors.New("gateway: the server certificate is not the certificate of the server")
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"backend/models"
+	pb "backend/proto"
+)
+
+// startGateway serves the services of db on a local gRPC server and returns an
+// HTTP test server for the gateway calling it.
+func startGateway(t *testing.T, db *sql.DB) *httptest.Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metricsUnaryInterceptor, errorUnaryInterceptor),
+		grpc.ChainStreamInterceptor(metricsStreamInterceptor, errorStreamInterceptor),
+	)
+	pb.RegisterResourceServiceServer(grpcServer, NewResourceServiceServer(Deps{DB: db}))
+	pb.RegisterAnimalRankingServiceServer(grpcServer, NewAnimalRankingServiceServer(Deps{DB: db}))
+	pb.RegisterCollectionServiceServer(grpcServer, NewCollectionServiceServer(Deps{DB: db}))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gateway, err := newGateway(ctx, listener.Addr().String(), insecure.NewCredentials())
+	if err != nil {
+		t.Fatalf("Failed to create gateway: %v", err)
+	}
+	srv := httptest.NewServer(gateway)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestGatewayListResources tests paging through the resources over REST with
+// the page tokens of the gRPC path.
+func TestGatewayListResources(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 25, 10, 0, 0, 0, time.UTC)
+	var resources []*models.Resource
+	for i, name := range []string{"Resource 1", "Resource 2", "Resource 3", "Resource 4", "Resource 5"} {
+		created := start.Add(time.Duration(i) * 5 * time.Minute)
+		resources = append(resources, &models.Resource{UUID: "uuid-" + name[len(name)-1:], Name: name, CreatedAt: created, UpdatedAt: created})
+	}
+	if err := models.InsertResourceBatch(ctx, db, resources); err != nil {
+		t.Fatalf("Failed to insert resources: %v", err)
+	}
+	srv := startGateway(t, db)
+
+	list := func(query url.Values) (int, []string, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/v1/resources?" + query.Encode())
+		if err != nil {
+			t.Fatalf("Failed to list resources: %v", err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Resources []struct {
+				Name string `json:"name"`
+			} `json:"resources"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var names []string
+		for _, r := range body.Resources {
+			names = append(names, r.Name)
+		}
+		return resp.StatusCode, names, body.NextPageToken
+	}
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected []string
+	}{
+		{"All by created_at", url.Values{"page_size": {"2"}}, []string{"Resource 1", "Resource 2", "Resource 3", "Resource 4", "Resource 5"}},
+		{"Descending by name", url.Values{"page_size": {"2"}, "order": {"DESC"}, "sort_column": {"RESOURCE_NAME"}}, []string{"Resource 5", "Resource 4", "Resource 3", "Resource 2", "Resource 1"}},
+		{"Filtered", url.Values{"page_size": {"2"}, "filter": {`uuid=uuid-2 AND uuid=uuid-4 AND name="Resource 4"`}}, []string{"Resource 4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			query := tt.query
+			for pages := 0; ; pages++ {
+				if pages > len(tt.expected) {
+					t.Fatalf("Pagination did not terminate")
+				}
+				code, page, token := list(query)
+				if code != http.StatusOK {
+					t.Fatalf("Expected status 200, got: %d", code)
+				}
+				if len(page) == 0 {
+					break
+				}
+				names = append(names, page...)
+				query.Set("page_token", token)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected names: %v, got: %v", tt.expected, names)
+			}
+		})
+	}
+
+	// Invalid arguments are reported as 400 Bad Request.
+	if code, _, _ := list(url.Values{"filter": {"name"}}); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid filter, got: %d", code)
+	}
+}
+
+// TestGatewayDescribeCollection tests describing a collection over REST.
+func TestGatewayDescribeCollection(t *testing.T) {
+	srv := startGateway(t, nil)
+	resp, err := http.Get(srv.URL + "/v1/collections/animal_rankings")
+	if err != nil {
+		t.Fatalf("Failed to describe the collection: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Collections []struct {
+			Name     string `json:"name"`
+			SortKeys []struct {
+				SortColumn string `json:"sortColumn"`
+			} `json:"sortKeys"`
+		} `json:"collections"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Collections) != 1 || body.Collections[0].Name != "animal_rankings" || len(body.Collections[0].SortKeys) != 2 {
+		t.Errorf("Expected the animal rankings and their 2 sort keys, got: %+v", body)
+	}
+	resp, err = http.Get(srv.URL + "/v1/collections/unknown")
+	if err != nil {
+		t.Fatalf("Failed to describe the collection: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown collection, got: %d", resp.StatusCode)
+	}
+}
+
+// TestGatewayOpenAPI tests that the gateway serves the OpenAPI document.
+func TestGatewayOpenAPI(t *testing.T) {
+	srv := startGateway(t, nil)
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("Failed to get OpenAPI document: %v", err)
+	}
+	defer resp.Body.Close()
+	var doc struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+	if _, ok := doc.Paths["/v1/resources"]; !ok || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		t.Errorf("Expected a JSON document with the /v1/resources path, got paths: %v", doc.Paths)
+	}
+}
+
+// TestGatewayClientAddr tests that the gateway forwards the address of its
+// HTTP clients, and not the one set by their headers, to key their rate limits.
+func TestGatewayClientAddr(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	keys := make(chan string, 1)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		keys <- clientKey(ctx)
+		return handler(ctx, req)
+	}))
+	pb.RegisterCollectionServiceServer(grpcServer, NewCollectionServiceServer(Deps{DB: initTestDB(t)}))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gateway, err := newGateway(ctx, listener.Addr().String(), insecure.NewCredentials())
+	if err != nil {
+		t.Fatalf("Failed to create gateway: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/collections", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("Grpc-Metadata-X-Gateway-Client-Addr", "198.51.100.1")
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got: %d %s", rec.Code, rec.Body)
+	}
+	if key := <-keys; key != "peer:203.0.113.7" {
+		t.Errorf("Expected the key of the HTTP client, got: %s", key)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// pinger is implemented by *sql.DB.
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// dbHealthChecker pings the database every interval, and reports the server
+// and each of its services SERVING while the pings succeed, and NOT_SERVING
+// otherwise.
+type dbHealthChecker struct {
+	db       pinger
+	health   *health.Server
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+
+	// serving is the last status set, to log the changes.
+	serving *bool
+}
+
+// check pings the database once and updates the health statuses.
+func (c *dbHealthChecker) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	err := c.db.PingContext(ctx)
+	serving := err == nil
+	if c.serving == nil || *c.serving != serving {
+		if serving {
+			slog.Info("Database is reachable: serving")
+		} else {
+			slog.Warn("Database is unreachable: not serving", "err", err)
+		}
+	}
+	c.serving = &serving
+
+	st := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if serving {
+		st = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	c.health.SetServingStatus("", st)
+	for _, service := range c.services {
+		c.health.SetServingStatus(service, st)
+	}
+}
+
+// run checks the database right away and then every interval, until ctx is
+// done.
+func (c *dbHealthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// shutdown stops the servers: the health statuses become NOT_SERVING for the
+// load balancers to stop routing calls, the HTTP servers stop accepting
+// requests, and the in-flight RPCs are drained for up to timeout before
+// being cancelled.
+func shutdown(grpcServer *grpc.Server, healthServer *health.Server, httpServers []*http.Server, timeout time.Duration) {
+	// Shutdown also ignores the later updates of the health checker.
+	healthServer.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, srv := range httpServers {
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("Failed to shut down HTTP server", "addr", srv.Addr, "err", err)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Warn("In-flight RPCs did not finish in time: cancelling them", "timeout", timeout)
+		grpcServer.Stop()
+		<-stopped
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakePinger is a database answering its pings with err.
+type fakePinger struct {
+	err error
+}
+
+// PingContext satisfies the pinger interface.
+func (p *fakePinger) PingContext(context.Context) error {
+	return p.err
+}
+
+// TestDBHealthChecker tests that the health statuses follow the pings of the
+// database.
+func TestDBHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	db := &fakePinger{}
+	healthServer := health.NewServer()
+	c := &dbHealthChecker{db: db, health: healthServer, services: []string{"backend.ResourceService"}, timeout: time.Second}
+
+	expect := func(want grpc_health_v1.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		for _, service := range []string{"", "backend.ResourceService"} {
+			resp, err := healthServer.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatalf("Failed to check %q: %v", service, err)
+			}
+			if resp.Status != want {
+				t.Errorf("Expected %q to be %v, got: %v", service, want, resp.Status)
+			}
+		}
+	}
+
+	c.check(ctx)
+	expect(grpc_health_v1.HealthCheckResponse_SERVING)
+	db.err = errors.New("connection refused")
+	c.check(ctx)
+	expect(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	db.err = nil
+	c.check(ctx)
+	expect(grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// Once shut down, the server stays NOT_SERVING.
+	healthServer.Shutdown()
+	c.check(ctx)
+	expect(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
+// TestShutdown tests that shutting down reports NOT_SERVING to the watchers,
+// and cancels the calls still in flight after the timeout.
+func TestShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	served := make(chan error, 1)
+	go func() { served <- grpcServer.Serve(listener) }()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// A watch never ends by itself, so it holds off the graceful stop.
+	watch, err := grpc_health_v1.NewHealthClient(conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got: %v, %v", resp, err)
+	}
+
+	start := time.Now()
+	shutdown(grpcServer, healthServer, nil, 100*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the shutdown to stop after its timeout, took: %v", elapsed)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING, got: %v, %v", resp, err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected Serve to return cleanly, got: %v", err)
+	}
+}
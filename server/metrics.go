@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"backend/sqlhook"
+)
+
+// The metrics of the server, registered by newMetricsRegistry.
+var (
+	// rpcHandled counts the completed RPCs by service, method and status code.
+	rpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	// rpcDuration observes the latency of the RPCs by service and method.
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Latency of the RPCs handled by the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_service", "grpc_method"})
+
+	// queryDuration observes the latency of the database queries by table and
+	// operation.
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Latency of the database queries.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"table", "op"})
+
+	// pageRows observes the number of rows returned per page by method.
+	pageRows = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "page_rows",
+		Help:    "Number of rows returned per page.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+	}, []string{"grpc_method"})
+
+	// pageSize observes the page sizes requested by method.
+	pageSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "page_size",
+		Help:    "Page sizes requested by the clients.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+	}, []string{"grpc_method"})
+)
+
+// newMetricsRegistry returns a registry with the metrics of the server, the
+// pool statistics of db, and the Go runtime and process metrics.
+func newMetricsRegistry(db *sql.DB) *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		rpcHandled,
+		rpcDuration,
+		queryDuration,
+		pageRows,
+		pageSize,
+		collectors.NewDBStatsCollector(db, "platform"),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// metricsHandler returns the HTTP handler serving the metrics of reg at
+// /metrics.
+func metricsHandler(reg *prometheus.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// splitMethod splits a full method name, /package.Service/Method, into its
+// service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
+
+// observeRPC records an RPC completed after start with err.
+func observeRPC(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	rpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// metricsUnaryInterceptor records the count, status code and latency of unary
+// RPCs. It runs before the error interceptors, to record translated codes.
+func metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+// metricsStreamInterceptor records the count, status code and latency of
+// streaming RPCs.
+func metricsStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeRPC(info.FullMethod, start, err)
+	return err
+}
+
+// observePage records the size requested for a page of method and the number
+// of rows returned.
+func observePage(method string, size, rows int) {
+	pageSize.WithLabelValues(method).Observe(float64(size))
+	pageRows.WithLabelValues(method).Observe(float64(rows))
+}
+
+// queryMetricsHook records the latency of the database queries.
+func queryMetricsHook(ctx context.Context, q sqlhook.Query) (context.Context, func(int, error)) {
+	start := time.Now()
+	return ctx, func(int, error) {
+		queryDuration.WithLabelValues(q.Table, q.Op).Observe(time.Since(start).Seconds())
+	}
+}
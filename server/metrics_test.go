@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"backend/models"
+)
+
+// TestMetrics tests the RPC, query and page metrics recorded for a request
+// through the gateway, and the pool statistics of the registry.
+func TestMetrics(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	resources := []*models.Resource{{UUID: "uuid-1", Name: "Resource 1"}, {UUID: "uuid-2", Name: "Resource 2"}}
+	if err := models.InsertResourceBatch(ctx, db, resources); err != nil {
+		t.Fatalf("Failed to insert resources: %v", err)
+	}
+	srv := startGateway(t, db)
+
+	// The metrics are global, so compare them before and after the requests.
+	ok := rpcHandled.WithLabelValues("backend.ResourceService", "ListResources", "OK")
+	invalid := rpcHandled.WithLabelValues("backend.ResourceService", "ListResources", "InvalidArgument")
+	handledOK, handledInvalid := testutil.ToFloat64(ok), testutil.ToFloat64(invalid)
+	queries := sampleCount(t, queryDuration.WithLabelValues("resources", "SELECT"))
+	pages := sampleCount(t, pageRows.WithLabelValues("ListResources"))
+
+	for _, query := range []string{"page_size=10", "filter=name"} {
+		resp, err := http.Get(srv.URL + "/v1/resources?" + query)
+		if err != nil {
+			t.Fatalf("Failed to list resources: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := testutil.ToFloat64(ok) - handledOK; got != 1 {
+		t.Errorf("Expected 1 OK ListResources RPC, got: %v", got)
+	}
+	if got := testutil.ToFloat64(invalid) - handledInvalid; got != 1 {
+		t.Errorf("Expected 1 InvalidArgument ListResources RPC, got: %v", got)
+	}
+	if got := sampleCount(t, queryDuration.WithLabelValues("resources", "SELECT")) - queries; got != 1 {
+		t.Errorf("Expected 1 query of the resources table, got: %d", got)
+	}
+	if got := sampleCount(t, pageRows.WithLabelValues("ListResources")) - pages; got != 1 {
+		t.Errorf("Expected 1 page of resources, got: %d", got)
+	}
+
+	n, err := testutil.GatherAndCount(newMetricsRegistry(db), "go_sql_open_connections", "grpc_server_handling_seconds", "page_size")
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if n < 3 {
+		t.Errorf("Expected the pool, RPC and page size metrics, got %d series", n)
+	}
+}
+
+// sampleCount returns the number of observations of a histogram.
+func sampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// methodBudget is the token bucket of each client for the methods matching
+// Method, a path.Match pattern of full method names: Rate calls per second,
+// in bursts of up to Burst calls.
+type methodBudget struct {
+	Method string
+	Rate   rate.Limit
+	Burst  int
+}
+
+// defaultBudgets give the list queries, which may scan many rows, a smaller
+// budget than the other RPCs. The first budget matching a method applies.
+var defaultBudgets = []methodBudget{
+	{"/backend.*/List*", 10, 20},
+	{"/backend.*/Stream*", 2, 4},
+	{"/backend.*/Export*", 2, 4},
+	{"/backend.*/Sync*", 10, 20},
+	{"/backend.*/*", 50, 100},
+}
+
+// inFlightMethods are the patterns of the list queries whose concurrent calls
+// are capped per client.
+var inFlightMethods = []string{
+	"/backend.*/List*",
+	"/backend.*/Stream*",
+	"/backend.*/Export*",
+	"/backend.*/Sync*",
+}
+
+// clientIdleTimeout is how long the buckets of an idle client are kept.
+const clientIdleTimeout = 10 * time.Minute
+
+// clientLimits are the token buckets and in-flight list queries of a client.
+type clientLimits struct {
+	buckets  map[int]*rate.Limiter
+	inFlight int
+	lastSeen time.Time
+}
+
+// rateLimiter limits the calls of each client, identified by its principal or
+// its peer address, with the token bucket of the budget of each method, and
+// caps its concurrent list queries.
+type rateLimiter struct {
+	budgets     []methodBudget
+	maxInFlight int
+	now         func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimits
+	lastSweep time.Time
+}
+
+// newRateLimiter returns a rate limiter of budgets allowing up to maxInFlight
+// concurrent list queries per client, or no cap when maxInFlight is 0.
+func newRateLimiter(budgets []methodBudget, maxInFlight int) *rateLimiter {
+	return &rateLimiter{
+		budgets:     budgets,
+		maxInFlight: maxInFlight,
+		now:         time.Now,
+		clients:     make(map[string]*clientLimits),
+	}
+}
+
+// clientKey identifies the client of ctx: its principal when authenticated,
+// or the host of its peer address. The requests of the gateway are identified
+// by the address of their HTTP client, which only a peer on the host of the
+// server, as the gateway is, is trusted to forward.
+func clientKey(ctx context.Context) string {
+	if p, ok := principalFromContext(ctx); ok {
+		return "principal:" + p.Subject
+	}
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		addr := hostOf(pr.Addr)
+		if ip := net.ParseIP(addr); ip != nil && (ip.IsLoopback() || pr.LocalAddr != nil && addr == hostOf(pr.LocalAddr)) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if v := md.Get(clientAddrMetadata); len(v) != 0 {
+				addr = v[len(v)-1]
+			}
+		}
+		return "peer:" + addr
+	}
+	return "unknown"
+}
+
+// hostOf returns the host of addr.
+func hostOf(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// acquire takes a token of the budget of method for the client of ctx, and an
+// in-flight slot for list queries. It returns the function releasing the slot,
+// or a ResourceExhausted status and the delay after which to retry.
+func (l *rateLimiter) acquire(ctx context.Context, method string) (func(), time.Duration, error) {
+	key := clientKey(ctx)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	c, ok := l.clients[key]
+	if !ok {
+		c = &clientLimits{buckets: make(map[int]*rate.Limiter)}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+
+	capped := l.maxInFlight > 0 && matchMethod(inFlightMethods, method)
+	if capped && c.inFlight >= l.maxInFlight {
+		return nil, time.Second, status.Errorf(codes.ResourceExhausted, "too many concurrent list queries: at most %d are allowed", l.maxInFlight)
+	}
+	if i, budget, ok := l.budget(method); ok {
+		bucket, ok := c.buckets[i]
+		if !ok {
+			bucket = rate.NewLimiter(budget.Rate, budget.Burst)
+			c.buckets[i] = bucket
+		}
+		r := bucket.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); !r.OK() || delay > 0 {
+			r.CancelAt(now)
+			if !r.OK() {
+				delay = time.Second
+			}
+			return nil, delay, status.Errorf(codes.ResourceExhausted, "rate limit of %v calls per second exceeded", float64(budget.Rate))
+		}
+	}
+	if !capped {
+		return func() {}, 0, nil
+	}
+	c.inFlight++
+	return func() {
+		l.mu.Lock()
+		c.inFlight--
+		l.mu.Unlock()
+	}, 0, nil
+}
+
+// budget returns the first budget matching method and its index.
+func (l *rateLimiter) budget(method string) (int, methodBudget, bool) {
+	for i, budget := range l.budgets {
+		if matchMethod([]string{budget.Method}, method) {
+			return i, budget, true
+		}
+	}
+	return 0, methodBudget{}, false
+}
+
+// sweep forgets the clients idle for clientIdleTimeout, at most once a
+// minute. l.mu must be held.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, c := range l.clients {
+		if c.inFlight == 0 && now.Sub(c.lastSeen) > clientIdleTimeout {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// retryAfter returns the metadata and status of a rejected call, telling the
+// client to retry after delay.
+func retryAfter(err error, delay time.Duration) (metadata.MD, error) {
+	seconds := int64((delay + time.Second - 1) / time.Second)
+	md := metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10))
+	if detailed, derr := status.Convert(err).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); derr == nil {
+		err = detailed.Err()
+	}
+	return md, err
+}
+
+// unaryInterceptor limits unary calls.
+func (l *rateLimiter) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, delay, err := l.acquire(ctx, info.FullMethod)
+	if err != nil {
+		md, err := retryAfter(err, delay)
+		grpc.SetHeader(ctx, md)
+		return nil, err
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+// streamInterceptor limits streaming calls, holding their in-flight slot until
+// the stream ends.
+func (l *rateLimiter) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, delay, err := l.acquire(ss.Context(), info.FullMethod)
+	if err != nil {
+		md, err := retryAfter(err, delay)
+		ss.SetHeader(md)
+		return err
+	}
+	defer release()
+	return handler(srv, ss)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TestRateLimiter tests the token buckets of the clients and the cap of their
+// in-flight list queries.
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 9, 25, 10, 0, 0, 0, time.UTC)
+	l := newRateLimiter([]methodBudget{
+		{"/backend.*/List*", 1, 2},
+		{"/backend.*/*", 100, 100},
+	}, 1)
+	l.now = func() time.Time { return now }
+
+	const (
+		list = "/backend.ResourceService/ListResources"
+		get  = "/backend.ResourceService/GetResource"
+	)
+	alice := withPrincipal(context.Background(), &principal{Subject: "alice"})
+	bob := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 4242}})
+
+	call := func(ctx context.Context, method string) (time.Duration, error) {
+		t.Helper()
+		release, delay, err := l.acquire(ctx, method)
+		if err == nil {
+			release()
+		}
+		return delay, err
+	}
+
+	// The burst of alice is spent, but not the budget of bob or of Get.
+	for i := 0; i < 2; i++ {
+		if _, err := call(alice, list); err != nil {
+			t.Fatalf("Expected call %d within the burst to succeed, got: %v", i, err)
+		}
+	}
+	delay, err := call(alice, list)
+	if status.Code(err) != codes.ResourceExhausted || delay != time.Second {
+		t.Errorf("Expected ResourceExhausted retrying after 1s, got: %v after %v", err, delay)
+	}
+	if _, err := call(bob, list); err != nil {
+		t.Errorf("Expected another client to succeed, got: %v", err)
+	}
+	if _, err := call(alice, get); err != nil {
+		t.Errorf("Expected another method budget to succeed, got: %v", err)
+	}
+
+	// The bucket refills over time.
+	now = now.Add(time.Second)
+	if _, err := call(alice, list); err != nil {
+		t.Errorf("Expected a call after the refill to succeed, got: %v", err)
+	}
+
+	// Only one list query of a client may be in flight.
+	now = now.Add(time.Minute)
+	release, _, err := l.acquire(bob, list)
+	if err != nil {
+		t.Fatalf("Expected the first list query to succeed, got: %v", err)
+	}
+	if _, err := call(bob, list); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted for a concurrent list query, got: %v", err)
+	}
+	if _, err := call(bob, get); err != nil {
+		t.Errorf("Expected a concurrent Get to succeed, got: %v", err)
+	}
+	release()
+	if _, err := call(bob, list); err != nil {
+		t.Errorf("Expected a list query after the release to succeed, got: %v", err)
+	}
+}
+
+// TestRetryAfter tests the retry-after metadata and RetryInfo detail of
+// rejected calls.
+func TestRetryAfter(t *testing.T) {
+	md, err := retryAfter(status.Error(codes.ResourceExhausted, "rate limit exceeded"), 1500*time.Millisecond)
+	if got := md.Get("retry-after"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("Expected retry-after 2, got: %v", got)
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got: %v", st.Code())
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			if info.RetryDelay.AsDuration() != 1500*time.Millisecond {
+				t.Errorf("Expected a retry delay of 1.5s, got: %v", info.RetryDelay.AsDuration())
+			}
+			return
+		}
+	}
+	t.Errorf("Expected a RetryInfo detail, got: %v", st.Details())
+}
+
+// TestClientKey tests identifying the clients by principal, by peer address,
+// and by the HTTP client address forwarded by the gateway.
+func TestClientKey(t *testing.T) {
+	forwarded := metadata.Pairs(clientAddrMetadata, "203.0.113.7")
+	local := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 9090}
+	peerCtx := func(ip net.IP, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: ip, Port: 4242}, LocalAddr: local})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"Principal", withPrincipal(peerCtx(net.IPv4(10, 0, 0, 2), nil), &principal{Subject: "alice"}), "principal:alice"},
+		{"Remote peer", peerCtx(net.IPv4(10, 0, 0, 2), nil), "peer:10.0.0.2"},
+		{"Remote peer forwarding an address", peerCtx(net.IPv4(10, 0, 0, 2), forwarded), "peer:10.0.0.2"},
+		{"Gateway", peerCtx(net.IPv4(127, 0, 0, 1), forwarded), "peer:203.0.113.7"},
+		{"Gateway dialing the server address", peerCtx(net.IPv4(10, 0, 0, 1), forwarded), "peer:203.0.113.7"},
+		{"Loopback peer", peerCtx(net.IPv6loopback, nil), "peer:::1"},
+		{"No peer", context.Background(), "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientKey(tt.ctx); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
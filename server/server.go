@@ -0,0 +1,210 @@
+// Package server implements the gRPC services of the resources and animal
+// rankings and the description of their collections, and serves them with the
+// REST/JSON gateway, the health checks and the Prometheus metrics.
+//
+// The services are constructed with their dependencies by
+// [NewResourceServiceServer], [NewAnimalRankingServiceServer] and
+// [NewCollectionServiceServer], so that they can run against any database.
+// [New] wires them into a [Server] configured by a [config.Config].
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"backend/config"
+	pb "backend/proto"
+	"backend/sqlhook"
+
+	_ "github.com/go-sql-driver/mysql" // Import the MySQL driver
+)
+
+// OpenDB opens the MySQL database of cfg with its pool settings, observing
+// its queries for the metrics and traces.
+func OpenDB(cfg *config.Config) (*sql.DB, error) {
+	db, err := sqlhook.Open("mysql", cfg.DataSourceName(), queryMetricsHook, queryTraceHook)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	return db, nil
+}
+
+// SetupTracing installs the global tracer provider exporting the spans with
+// the exporter of cfg. It returns the function flushing the spans.
+func SetupTracing(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	exporter, err := newSpanExporter(ctx, cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	tp := newTracerProvider(exporter)
+	setTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Server serves the services over gRPC and the REST/JSON gateway, along with
+// the health checks and the metrics.
+type Server struct {
+	cfg     *config.Config
+	grpc    *grpc.Server
+	health  *health.Server
+	checker *dbHealthChecker
+	metrics *http.Server
+	// gatewayCreds secure the connection of the gateway to the gRPC server.
+	gatewayCreds credentials.TransportCredentials
+}
+
+// New returns the server of the services of d, configured by d.Config.
+func New(d Deps) (*Server, error) {
+	if d.Config == nil {
+		d.Config = config.Default()
+	}
+	cfg := d.Config
+
+	// Authenticate the callers with the API keys and JWKS files, if set, and
+	// the client certificates verified by the client CAs.
+	auth, err := newAuthorizer(cfg.Auth.APIKeysFile, cfg.Auth.JWKSFile, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience, cfg.TLS.ClientCAFile != "")
+	if err != nil {
+		return nil, err
+	}
+	unary := []grpc.UnaryServerInterceptor{metricsUnaryInterceptor, errorUnaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{metricsStreamInterceptor, errorStreamInterceptor}
+	if auth != nil {
+		unary = append(unary, auth.unaryInterceptor)
+		stream = append(stream, auth.streamInterceptor)
+	} else {
+		slog.Warn("Authentication is disabled: set auth.api_keys_file, auth.jwks_file or tls.client_ca_file to enable it")
+	}
+
+	// Limit the calls of each client, once authenticated, and bound the
+	// queries of the unary calls. The streams and exports bound each of their
+	// batches.
+	limiter := newRateLimiter(defaultBudgets, cfg.Limits.MaxInFlightListQueries)
+	unary = append(unary, limiter.unaryInterceptor, timeoutUnaryInterceptor(cfg.Limits.QueryTimeout))
+	stream = append(stream, limiter.streamInterceptor)
+
+	// Trace and record metrics of the RPCs, translate handler errors to
+	// status codes, authorize and rate limit the calls, and bound their
+	// queries.
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	s := &Server{cfg: cfg, gatewayCreds: insecure.NewCredentials()}
+	if cfg.TLS.Enabled() {
+		certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.serverConfig())))
+		s.gatewayCreds = pinnedCredentials(func() *tls.Certificate {
+			cert, _ := certs.current()
+			return cert
+		})
+	}
+	s.grpc = grpc.NewServer(opts...)
+
+	// Register the ResourceServiceServer, AnimalRankingServiceServer and
+	// CollectionServiceServer.
+	pb.RegisterResourceServiceServer(s.grpc, NewResourceServiceServer(d))
+	pb.RegisterAnimalRankingServiceServer(s.grpc, NewAnimalRankingServiceServer(d))
+	pb.RegisterCollectionServiceServer(s.grpc, NewCollectionServiceServer(d))
+
+	// Register the gRPC health check service, reporting the services as
+	// serving while the database answers its pings.
+	s.health = health.NewServer()
+	grpc_health_v1.RegisterHealthServer(s.grpc, s.health)
+	s.checker = &dbHealthChecker{
+		db:       d.DB,
+		health:   s.health,
+		services: []string{pb.ResourceService_ServiceDesc.ServiceName, pb.AnimalRankingService_ServiceDesc.ServiceName},
+		interval: 5 * time.Second,
+		timeout:  2 * time.Second,
+	}
+
+	// Enable reflection for grpcurl and other tools.
+	reflection.Register(s.grpc)
+
+	// Serve the Prometheus metrics, including the pool statistics of the
+	// database.
+	s.metrics = &http.Server{Addr: cfg.Server.MetricsAddr, Handler: metricsHandler(newMetricsRegistry(d.DB))}
+	return s, nil
+}
+
+// Run serves until ctx is done or a listener fails, and then shuts down,
+// draining the in-flight RPCs for up to the shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.cfg.Server.GRPCAddr)
+	if err != nil {
+		return err
+	}
+
+	// Serve the REST/JSON gateway, which calls the gRPC server.
+	gatewayCtx, cancelGateway := context.WithCancel(context.Background())
+	defer cancelGateway()
+	gateway, err := newGateway(gatewayCtx, dialAddr(listener.Addr()), s.gatewayCreds)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	gatewayServer := &http.Server{Addr: s.cfg.Server.HTTPAddr, Handler: gateway}
+
+	checkerCtx, cancelChecker := context.WithCancel(ctx)
+	defer cancelChecker()
+	go s.checker.run(checkerCtx)
+
+	errs := make(chan error, 3)
+	go func() {
+		slog.Info("Metrics server is listening", "addr", s.metrics.Addr)
+		if err := s.metrics.ListenAndServe(); err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+	go func() {
+		slog.Info("HTTP gateway is listening", "addr", gatewayServer.Addr)
+		if err := gatewayServer.ListenAndServe(); err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+	go func() {
+		slog.Info("gRPC server is listening", "addr", listener.Addr().String(), "tls", s.cfg.TLS.Enabled())
+		if err := s.grpc.Serve(listener); err != nil {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+	slog.Info("Shutting down")
+	shutdown(s.grpc, s.health, []*http.Server{gatewayServer, s.metrics}, s.cfg.Server.ShutdownTimeout)
+	return err
+}
+
+// dialAddr returns the address to dial the listener at addr from the same
+// host, replacing the unspecified addresses with the loopback.
+func dialAddr(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+	return net.JoinHostPort("localhost", strconv.Itoa(tcp.Port))
+}
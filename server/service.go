@@ -0,0 +1,697 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"backend/config"
+	"backend/models"
+	"backend/paginator"
+	pb "backend/proto" // Update this import to your generated protobuf package path.
+)
+
+// Deps are the dependencies of the services.
+type Deps struct {
+	// DB is the database of the models.
+	DB *sql.DB
+	// Clock returns the current time; time.Now by default.
+	Clock func() time.Time
+	// Config is the configuration of the server; config.Default() by
+	// default.
+	Config *config.Config
+	// Cursors encodes the page tokens and watermarks; JSONCursorCodec by
+	// default.
+	Cursors CursorCodec
+}
+
+// service holds the dependencies shared by the services.
+type service struct {
+	db           *sql.DB
+	now          func() time.Time
+	paging       config.Paging
+	queryTimeout time.Duration
+	cursors      CursorCodec
+}
+
+// newService returns the service of d, applying the defaults.
+func newService(d Deps) service {
+	s := service{db: d.DB, now: d.Clock, cursors: d.Cursors}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.cursors == nil {
+		s.cursors = JSONCursorCodec{}
+	}
+	cfg := d.Config
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	s.paging = cfg.Paging
+	s.queryTimeout = cfg.Limits.QueryTimeout
+	return s
+}
+
+// resourceSortColumns map the sort columns of the resource requests to their
+// columns.
+var resourceSortColumns = map[pb.ResourceSortColumn]string{
+	pb.ResourceSortColumn_RESOURCE_CREATED_AT: "created_at",
+	pb.ResourceSortColumn_RESOURCE_NAME:       "name",
+}
+
+// ResourceServiceServer is the server implementation for ResourceService.
+type ResourceServiceServer struct {
+	pb.UnimplementedResourceServiceServer
+	service
+}
+
+// NewResourceServiceServer returns the ResourceService of the dependencies d.
+func NewResourceServiceServer(d Deps) *ResourceServiceServer {
+	return &ResourceServiceServer{service: newService(d)}
+}
+
+// ListResources implements the ListResources RPC.
+func (s *ResourceServiceServer) ListResources(ctx context.Context, req *pb.ListResourcesRequest) (*pb.ListResourcesResponse, error) {
+	// Implement your pagination logic here using req parameters.
+	column := resourceSortColumns[req.SortColumn]
+	where, err := timeConditions(req)
+	if err != nil {
+		return nil, err
+	}
+	filters := convertStringMapToInterfaceMap(req.GetFilters())
+	if err := parseFilter(req.Filter, filters); err != nil {
+		return nil, err
+	}
+	limit := int(req.Limit)
+	if req.PageSize > 0 {
+		limit = int(req.PageSize)
+	}
+	limit = s.paging.PageSize(limit)
+
+	// Resume after the page token, or the deprecated key.
+	var key interface{}
+	if req.PageToken != "" {
+		if key, err = decodePageToken(s.cursors, "page_token", req.PageToken, column, req.Order); err != nil {
+			return nil, err
+		}
+	} else if req.Key != "" {
+		key = req.Key
+	}
+
+	// Fetch resources using pagination logic.
+	resources, k, err := models.ResourceKeysetPage(ctx, s.db, column, key, limit, req.Order.String(), filters, where...)
+	if err != nil {
+		return nil, err
+	}
+	observePage("ListResources", limit, len(resources))
+
+	// Convert resources to protobuf format.
+	resp := &pb.ListResourcesResponse{
+		Resources: resourcesToPB(resources),
+	}
+	if k == nil {
+		return resp, nil
+	}
+	next := pageToken{Column: column, Order: req.Order.String(), ID: &k.ID}
+	switch req.SortColumn {
+	case pb.ResourceSortColumn_RESOURCE_CREATED_AT:
+		resp.NextKey, next.Time = k.CreatedAt.String(), &k.CreatedAt
+	case pb.ResourceSortColumn_RESOURCE_NAME:
+		resp.NextKey, next.String = k.Name, &k.Name
+	default:
+		return nil, invalidArgument("sort_column", fmt.Sprintf("cannot page by %v", req.SortColumn))
+	}
+	if resp.NextPageToken, err = s.cursors.Encode(next); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseFilter parses a filter expression of column=value terms joined by AND
+// into filters. Values may be double quoted, and a column given several values
+// matches any of them.
+func parseFilter(filter string, filters map[string]interface{}) error {
+	if strings.TrimSpace(filter) == "" {
+		return nil
+	}
+	for _, term := range strings.Split(filter, " AND ") {
+		column, value, ok := strings.Cut(term, "=")
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return invalidArgument("filter", fmt.Sprintf("%q is not a column=value term", strings.TrimSpace(term)))
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		switch v := filters[column].(type) {
+		case nil:
+			filters[column] = value
+		case string:
+			filters[column] = []string{v, value}
+		case []string:
+			filters[column] = append(v, value)
+		}
+	}
+	return nil
+}
+
+// StreamResources implements the StreamResources RPC. It walks the keyset pages
+// of the sort column and sends one page per message. Send blocks while the
+// client's flow control window is full, so at most one page is buffered.
+func (s *ResourceServiceServer) StreamResources(req *pb.StreamResourcesRequest, stream pb.ResourceService_StreamResourcesServer) error {
+	ctx := stream.Context()
+	column := resourceSortColumns[req.SortColumn]
+	if column == "" {
+		return invalidArgument("sort_column", fmt.Sprintf("cannot sort by %v", req.SortColumn))
+	}
+	batchSize := s.paging.StreamBatchSize(int(req.BatchSize))
+	filters := convertStringMapToInterfaceMap(req.GetFilters())
+	where, err := timeConditions(req)
+	if err != nil {
+		return err
+	}
+
+	// Resume after the cursor, or the deprecated key.
+	var key interface{}
+	if req.Cursor != "" {
+		if key, err = decodePageToken(s.cursors, "cursor", req.Cursor, column, req.Order); err != nil {
+			return err
+		}
+	} else if req.Key != "" {
+		key = req.Key
+	}
+	for {
+		// Stop as soon as the client cancels or disconnects.
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		// Bound each batch by the query timeout, as the stream may be long.
+		qctx, cancel := withQueryTimeout(ctx, s.queryTimeout)
+		resources, last, err := models.ResourceKeysetPage(qctx, s.db, column, key, batchSize, req.Order.String(), filters, where...)
+		cancel()
+		if err != nil {
+			return deadlineError(qctx, err)
+		}
+		observePage("StreamResources", batchSize, len(resources))
+		if len(resources) == 0 {
+			return nil
+		}
+		// Resume after the typed key and id of the last resource.
+		var value interface{} = last.CreatedAt
+		if column == "name" {
+			value = last.Name
+		}
+		key = paginator.AfterRow(value, last.ID)
+		cursor, err := encodePageToken(s.cursors, column, req.Order, value, last.ID)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.StreamResourcesResponse{
+			Resources: resourcesToPB(resources),
+			Cursor:    cursor,
+		}); err != nil {
+			return err
+		}
+		if len(resources) < batchSize {
+			return nil
+		}
+	}
+}
+
+// defaultSyncLimit is the number of changes returned by SyncResources when the
+// request does not set a limit.
+const defaultSyncLimit = 100
+
+// SyncResources implements the SyncResources RPC.
+func (s *ResourceServiceServer) SyncResources(ctx context.Context, req *pb.SyncResourcesRequest) (*pb.SyncResourcesResponse, error) {
+	var watermark models.ResourceWatermark
+	if req.Watermark != "" {
+		if err := s.cursors.Decode(req.Watermark, &watermark); err != nil {
+			return nil, invalidArgument("watermark", err.Error())
+		}
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultSyncLimit
+	}
+	limit = min(limit, s.paging.MaxPageSize)
+
+	// Fetch the resources changed since the watermark, including soft deleted ones.
+	resources, next, err := models.ResourceChangesSince(ctx, s.db, watermark, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the changes into updated resources and tombstones.
+	resp := &pb.SyncResourcesResponse{
+		Resources: []*pb.Resource{},
+		Deleted:   []*pb.ResourceTombstone{},
+		HasMore:   len(resources) == limit,
+	}
+	for _, r := range resources {
+		if r.Deleted() {
+			resp.Deleted = append(resp.Deleted, &pb.ResourceTombstone{
+				Id:         int32(r.ID),
+				Uuid:       r.UUID,
+				DeletedAt:  r.DeletedAt.Time.String(),
+				DeleteTime: timestamppb.New(r.DeletedAt.Time),
+			})
+			continue
+		}
+		resp.Resources = append(resp.Resources, resourceToPB(r))
+	}
+	if resp.Watermark, err = s.cursors.Encode(next); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetResource implements the GetResource RPC.
+func (s *ResourceServiceServer) GetResource(ctx context.Context, req *pb.GetResourceRequest) (*pb.Resource, error) {
+	var r *models.Resource
+	var err error
+	switch lookup := req.Lookup.(type) {
+	case *pb.GetResourceRequest_Id:
+		r, err = models.ResourceByID(ctx, s.db, int(lookup.Id))
+	case *pb.GetResourceRequest_Uuid:
+		r, err = models.ResourceByUUID(ctx, s.db, lookup.Uuid)
+	default:
+		return nil, invalidArgument("lookup", "id or uuid is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resourceToPB(r), nil
+}
+
+// CreateResource implements the CreateResource RPC.
+func (s *ResourceServiceServer) CreateResource(ctx context.Context, req *pb.CreateResourceRequest) (*pb.Resource, error) {
+	in := req.GetResource()
+	if in.GetName() == "" {
+		return nil, invalidArgument("resource.name", "is required")
+	}
+	now := s.now()
+	r := &models.Resource{
+		UUID:      in.Uuid,
+		Name:      in.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if r.UUID == "" {
+		r.UUID = uuid.NewString()
+	}
+	if err := r.Insert(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "created resource %d", r.ID)
+	return resourceToPB(r), nil
+}
+
+// UpdateResource implements the UpdateResource RPC. Only the fields of the
+// update mask are changed; an empty mask updates every updatable field.
+func (s *ResourceServiceServer) UpdateResource(ctx context.Context, req *pb.UpdateResourceRequest) (*pb.Resource, error) {
+	in := req.GetResource()
+	paths, err := updatePaths(req.UpdateMask, in, "name", "uuid")
+	if err != nil {
+		return nil, err
+	}
+	r, err := models.ResourceByID(ctx, s.db, int(in.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			if in.Name == "" {
+				return nil, invalidArgument("resource.name", "is required")
+			}
+			r.Name = in.Name
+		case "uuid":
+			if in.Uuid == "" {
+				return nil, invalidArgument("resource.uuid", "is required")
+			}
+			r.UUID = in.Uuid
+		}
+	}
+	if err := r.Update(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "updated %v of resource %d", paths, r.ID)
+	return resourceToPB(r), nil
+}
+
+// DeleteResource implements the DeleteResource RPC. The resource is soft
+// deleted, and reported as a tombstone by SyncResources.
+func (s *ResourceServiceServer) DeleteResource(ctx context.Context, req *pb.DeleteResourceRequest) (*emptypb.Empty, error) {
+	r, err := models.ResourceByID(ctx, s.db, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Delete(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "deleted resource %d", r.ID)
+	return &emptypb.Empty{}, nil
+}
+
+// updatePaths returns the fields of msg to update: the paths of mask, or all
+// of the updatable fields when mask is empty. It fails when a path is not a
+// field of msg or is not updatable.
+func updatePaths(mask *fieldmaskpb.FieldMask, msg proto.Message, updatable ...string) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return updatable, nil
+	}
+	if !mask.IsValid(msg) {
+		return nil, invalidArgument("update_mask", fmt.Sprintf("invalid paths %v", mask.GetPaths()))
+	}
+	mask.Normalize()
+	for _, path := range mask.Paths {
+		if !slices.Contains(updatable, path) {
+			return nil, invalidArgument("update_mask", fmt.Sprintf("field %q cannot be updated", path))
+		}
+	}
+	return mask.Paths, nil
+}
+
+// animalRankingSortColumns map the sort columns of the animal ranking requests
+// to their columns.
+var animalRankingSortColumns = map[pb.AnimalRankingSortColumn]string{
+	pb.AnimalRankingSortColumn_ANIMAL_RANK: "rank",
+	pb.AnimalRankingSortColumn_ANIMAL_NAME: "name",
+}
+
+// AnimalRankingServiceServer is the server implementation for AnimalRankingService.
+type AnimalRankingServiceServer struct {
+	pb.UnimplementedAnimalRankingServiceServer
+	service
+}
+
+// NewAnimalRankingServiceServer returns the AnimalRankingService of the
+// dependencies d.
+func NewAnimalRankingServiceServer(d Deps) *AnimalRankingServiceServer {
+	return &AnimalRankingServiceServer{service: newService(d)}
+}
+
+// ListAnimalRankings implements the ListAnimalRankings RPC.
+func (s *AnimalRankingServiceServer) ListAnimalRankings(ctx context.Context, req *pb.ListAnimalRankingsRequest) (*pb.ListAnimalRankingsResponse, error) {
+	// Implement your pagination logic here using req parameters.
+	column := animalRankingSortColumns[req.SortColumn]
+
+	// Resume after the typed key carried by the page token, or the deprecated
+	// rank key.
+	key, err := decodePageToken(s.cursors, "page_token", req.PageToken, column, req.Order)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil && req.Key != 0 {
+		if column != "rank" {
+			return nil, invalidArgument("key", fmt.Sprintf("cannot page by %v with a rank, use page_token", req.SortColumn))
+		}
+		key = int(req.Key)
+	}
+	where, err := timeConditions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch animal rankings using pagination logic.
+	limit := s.paging.PageSize(int(req.Limit))
+	rankings, last, err := models.AnimalRankingKeysetPage(ctx, s.db, column, key, limit, req.Order.String(), convertStringMapToInterfaceMap(req.GetFilters()), where...)
+	if err != nil {
+		return nil, err
+	}
+	observePage("ListAnimalRankings", limit, len(rankings))
+
+	// Convert animal rankings to protobuf format.
+	resp := &pb.ListAnimalRankingsResponse{
+		AnimalRankings: animalRankingsToPB(rankings),
+	}
+	if last == nil {
+		return resp, nil
+	}
+	resp.NextKey = int32(last.Rank)
+	next := pageToken{Column: column, Order: req.Order.String(), ID: &last.ID}
+	if column == "rank" {
+		next.Int = &last.Rank
+	} else {
+		next.String = &last.Name
+	}
+	if resp.NextPageToken, err = s.cursors.Encode(next); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StreamAnimalRankings implements the StreamAnimalRankings RPC. It walks the
+// keyset pages of the sort column and sends one page per message.
+func (s *AnimalRankingServiceServer) StreamAnimalRankings(req *pb.StreamAnimalRankingsRequest, stream pb.AnimalRankingService_StreamAnimalRankingsServer) error {
+	ctx := stream.Context()
+	column := animalRankingSortColumns[req.SortColumn]
+	if column == "" {
+		return invalidArgument("sort_column", fmt.Sprintf("cannot sort by %v", req.SortColumn))
+	}
+	batchSize := s.paging.StreamBatchSize(int(req.BatchSize))
+	filters := convertStringMapToInterfaceMap(req.GetFilters())
+	where, err := timeConditions(req)
+	if err != nil {
+		return err
+	}
+
+	// Resume after the cursor, or the deprecated key. Ranks are compared as
+	// integers.
+	var key interface{}
+	switch {
+	case req.Cursor != "":
+		if key, err = decodePageToken(s.cursors, "cursor", req.Cursor, column, req.Order); err != nil {
+			return err
+		}
+	case req.Key != "" && column == "rank":
+		rank, err := strconv.Atoi(req.Key)
+		if err != nil {
+			return invalidArgument("key", fmt.Sprintf("%q is not a rank", req.Key))
+		}
+		key = rank
+	case req.Key != "":
+		key = req.Key
+	}
+	for {
+		// Stop as soon as the client cancels or disconnects.
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		// Bound each batch by the query timeout, as the stream may be long.
+		qctx, cancel := withQueryTimeout(ctx, s.queryTimeout)
+		rankings, last, err := models.AnimalRankingKeysetPage(qctx, s.db, column, key, batchSize, req.Order.String(), filters, where...)
+		cancel()
+		if err != nil {
+			return deadlineError(qctx, err)
+		}
+		observePage("StreamAnimalRankings", batchSize, len(rankings))
+		if len(rankings) == 0 {
+			return nil
+		}
+		// Resume after the typed key and id of the last animal ranking.
+		var value interface{} = last.Name
+		if column == "rank" {
+			value = last.Rank
+		}
+		key = paginator.AfterRow(value, last.ID)
+		cursor, err := encodePageToken(s.cursors, column, req.Order, value, last.ID)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.StreamAnimalRankingsResponse{
+			AnimalRankings: animalRankingsToPB(rankings),
+			Cursor:         cursor,
+		}); err != nil {
+			return err
+		}
+		if len(rankings) < batchSize {
+			return nil
+		}
+	}
+}
+
+// GetAnimalRanking implements the GetAnimalRanking RPC.
+func (s *AnimalRankingServiceServer) GetAnimalRanking(ctx context.Context, req *pb.GetAnimalRankingRequest) (*pb.AnimalRanking, error) {
+	var ar *models.AnimalRanking
+	var err error
+	switch lookup := req.Lookup.(type) {
+	case *pb.GetAnimalRankingRequest_Id:
+		ar, err = models.AnimalRankingByID(ctx, s.db, int(lookup.Id))
+	case *pb.GetAnimalRankingRequest_Rank:
+		ar, err = models.AnimalRankingByRank(ctx, s.db, int(lookup.Rank))
+	default:
+		return nil, invalidArgument("lookup", "id or rank is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return animalRankingToPB(ar), nil
+}
+
+// CreateAnimalRanking implements the CreateAnimalRanking RPC.
+func (s *AnimalRankingServiceServer) CreateAnimalRanking(ctx context.Context, req *pb.CreateAnimalRankingRequest) (*pb.AnimalRanking, error) {
+	in := req.GetAnimalRanking()
+	if in.GetName() == "" {
+		return nil, invalidArgument("animal_ranking.name", "is required")
+	}
+	now := s.now()
+	ar := &models.AnimalRanking{
+		Rank:      int(in.Rank),
+		Name:      in.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := ar.Insert(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "created animal ranking %d", ar.ID)
+	return animalRankingToPB(ar), nil
+}
+
+// UpdateAnimalRanking implements the UpdateAnimalRanking RPC. Only the fields
+// of the update mask are changed; an empty mask updates every updatable field.
+func (s *AnimalRankingServiceServer) UpdateAnimalRanking(ctx context.Context, req *pb.UpdateAnimalRankingRequest) (*pb.AnimalRanking, error) {
+	in := req.GetAnimalRanking()
+	paths, err := updatePaths(req.UpdateMask, in, "rank", "name")
+	if err != nil {
+		return nil, err
+	}
+	ar, err := models.AnimalRankingByID(ctx, s.db, int(in.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		switch path {
+		case "rank":
+			ar.Rank = int(in.Rank)
+		case "name":
+			if in.Name == "" {
+				return nil, invalidArgument("animal_ranking.name", "is required")
+			}
+			ar.Name = in.Name
+		}
+	}
+	if err := ar.Update(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "updated %v of animal ranking %d", paths, ar.ID)
+	return animalRankingToPB(ar), nil
+}
+
+// DeleteAnimalRanking implements the DeleteAnimalRanking RPC.
+func (s *AnimalRankingServiceServer) DeleteAnimalRanking(ctx context.Context, req *pb.DeleteAnimalRankingRequest) (*emptypb.Empty, error) {
+	ar, err := models.AnimalRankingByID(ctx, s.db, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err := ar.Delete(ctx, s.db); err != nil {
+		return nil, err
+	}
+	auditf(ctx, "deleted animal ranking %d", ar.ID)
+	return &emptypb.Empty{}, nil
+}
+
+// resourceToPB converts a resource to its protobuf message.
+func resourceToPB(r *models.Resource) *pb.Resource {
+	return &pb.Resource{
+		Id:         int32(r.ID),
+		Uuid:       r.UUID,
+		Name:       r.Name,
+		CreatedAt:  r.CreatedAt.String(),
+		UpdatedAt:  r.UpdatedAt.String(),
+		CreateTime: timestamppb.New(r.CreatedAt),
+		UpdateTime: timestamppb.New(r.UpdatedAt),
+	}
+}
+
+// resourcesToPB converts resources to their protobuf messages.
+func resourcesToPB(resources []*models.Resource) []*pb.Resource {
+	pbResources := []*pb.Resource{}
+	for _, r := range resources {
+		pbResources = append(pbResources, resourceToPB(r))
+	}
+	return pbResources
+}
+
+// animalRankingToPB converts an animal ranking to its protobuf message.
+func animalRankingToPB(r *models.AnimalRanking) *pb.AnimalRanking {
+	return &pb.AnimalRanking{
+		Id:         int32(r.ID),
+		Rank:       int32(r.Rank),
+		Name:       r.Name,
+		CreatedAt:  r.CreatedAt.String(),
+		UpdatedAt:  r.UpdatedAt.String(),
+		CreateTime: timestamppb.New(r.CreatedAt),
+		UpdateTime: timestamppb.New(r.UpdatedAt),
+	}
+}
+
+// animalRankingsToPB converts animal rankings to their protobuf messages.
+func animalRankingsToPB(rankings []*models.AnimalRanking) []*pb.AnimalRanking {
+	pbRankings := []*pb.AnimalRanking{}
+	for _, r := range rankings {
+		pbRankings = append(pbRankings, animalRankingToPB(r))
+	}
+	return pbRankings
+}
+
+// timeRange is implemented by the requests with time range filters.
+type timeRange interface {
+	GetCreatedAfter() *timestamppb.Timestamp
+	GetCreatedBefore() *timestamppb.Timestamp
+	GetUpdatedAfter() *timestamppb.Timestamp
+}
+
+// timeFilters are the time range filters of the requests, comparing a column
+// to their timestamp with an operator. The bounds are exclusive.
+var timeFilters = []struct {
+	field  string
+	ts     func(timeRange) *timestamppb.Timestamp
+	column string
+	op     string
+	cond   func(column string, v interface{}) paginator.Condition
+}{
+	{"created_after", timeRange.GetCreatedAfter, "created_at", ">", paginator.Gt},
+	{"created_before", timeRange.GetCreatedBefore, "created_at", "<", paginator.Lt},
+	{"updated_after", timeRange.GetUpdatedAfter, "updated_at", ">", paginator.Gt},
+}
+
+// timeConditions returns the keyset conditions of the time range filters of
+// req.
+func timeConditions(req timeRange) ([]paginator.Condition, error) {
+	var where []paginator.Condition
+	for _, f := range timeFilters {
+		ts := f.ts(req)
+		if ts == nil {
+			continue
+		}
+		if err := ts.CheckValid(); err != nil {
+			return nil, invalidArgument(f.field, err.Error())
+		}
+		where = append(where, f.cond(f.column, ts.AsTime()))
+	}
+	return where, nil
+}
+
+// Convert map[string]string to map[string]interface{}
+func convertStringMapToInterfaceMap(input map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range input {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"backend/config"
+	"backend/models"
+	pb "backend/proto"
+	"backend/sqlhook"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// initTestDB returns an in-memory SQLite database with the resources and
+// animal_rankings tables, observed like the MySQL database.
+func initTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	testDB, err := sqlhook.Open("sqlite3", ":memory:", queryMetricsHook, queryTraceHook)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// The attached database only lives on this connection.
+	testDB.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`ATTACH DATABASE ':memory:' AS platform`,
+		`CREATE TABLE platform.resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			uuid VARCHAR(100) NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL DEFAULT NULL
+		)`,
+		`CREATE TABLE platform.animal_rankings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rank INT NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Set updated_at on changes as ON UPDATE CURRENT_TIMESTAMP(6) does
+		`CREATE TRIGGER platform.resources_updated_at AFTER UPDATE ON resources
+		WHEN NEW.updated_at IS OLD.updated_at
+		BEGIN
+			UPDATE resources SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+		END`,
+		`CREATE TRIGGER platform.animal_rankings_updated_at AFTER UPDATE ON animal_rankings
+		WHEN NEW.updated_at IS OLD.updated_at
+		BEGIN
+			UPDATE animal_rankings SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+		END`,
+	} {
+		if _, err := testDB.Exec(stmt); err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+	}
+	t.Cleanup(func() { testDB.Close() })
+	return testDB
+}
+
+// TestResourceCRUD tests creating, getting, updating and deleting a resource.
+func TestResourceCRUD(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	s := NewResourceServiceServer(Deps{DB: db})
+
+	created, err := s.CreateResource(ctx, &pb.CreateResourceRequest{Resource: &pb.Resource{Name: "Resource 1"}})
+	if err != nil {
+		t.Fatalf("Failed to create resource: %v", err)
+	}
+	if created.Id == 0 || created.Uuid == "" {
+		t.Errorf("Expected a generated id and uuid, got: %v", created)
+	}
+
+	got, err := s.GetResource(ctx, &pb.GetResourceRequest{Lookup: &pb.GetResourceRequest_Uuid{Uuid: created.Uuid}})
+	if err != nil {
+		t.Fatalf("Failed to get resource: %v", err)
+	}
+	if got.Id != created.Id || got.Name != "Resource 1" {
+		t.Errorf("Expected the created resource, got: %v", got)
+	}
+
+	// Only the fields of the mask are updated.
+	updated, err := s.UpdateResource(ctx, &pb.UpdateResourceRequest{
+		Resource:   &pb.Resource{Id: created.Id, Name: "Renamed", Uuid: "ignored"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update resource: %v", err)
+	}
+	if updated.Name != "Renamed" || updated.Uuid != created.Uuid {
+		t.Errorf("Expected only the name to be updated, got: %v", updated)
+	}
+
+	if _, err := s.DeleteResource(ctx, &pb.DeleteResourceRequest{Id: created.Id}); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	_, err = s.GetResource(ctx, &pb.GetResourceRequest{Lookup: &pb.GetResourceRequest_Id{Id: created.Id}})
+	if status.Code(statusError(err)) != codes.NotFound {
+		t.Errorf("Expected NotFound for a deleted resource, got: %v", err)
+	}
+}
+
+// prefixCodec encodes the cursors like JSONCursorCodec, with a prefix.
+type prefixCodec struct{ JSONCursorCodec }
+
+func (c prefixCodec) Encode(v interface{}) (string, error) {
+	s, err := c.JSONCursorCodec.Encode(v)
+	return "v1." + s, err
+}
+
+func (c prefixCodec) Decode(s string, v interface{}) error {
+	rest, ok := strings.CutPrefix(s, "v1.")
+	if !ok {
+		return errors.New("unknown cursor version")
+	}
+	return c.JSONCursorCodec.Decode(rest, v)
+}
+
+// TestServiceDeps tests that the services use the database, clock, paging
+// configuration and cursor codec they are constructed with.
+func TestServiceDeps(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := config.Default()
+	cfg.Paging.DefaultPageSize = 2
+	s := NewResourceServiceServer(Deps{
+		DB:      initTestDB(t),
+		Clock:   func() time.Time { return now },
+		Config:  cfg,
+		Cursors: prefixCodec{},
+	})
+	other := NewResourceServiceServer(Deps{DB: initTestDB(t)})
+
+	for _, name := range []string{"a", "b", "c"} {
+		created, err := s.CreateResource(ctx, &pb.CreateResourceRequest{Resource: &pb.Resource{Name: name}})
+		if err != nil {
+			t.Fatalf("Failed to create resource: %v", err)
+		}
+		if !created.CreateTime.AsTime().Equal(now) {
+			t.Errorf("Expected create time %v, got: %v", now, created.CreateTime.AsTime())
+		}
+	}
+
+	// The other service has its own database.
+	resp, err := other.ListResources(ctx, &pb.ListResourcesRequest{SortColumn: pb.ResourceSortColumn_RESOURCE_NAME})
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	if len(resp.Resources) != 0 {
+		t.Errorf("Expected no resources in the other database, got: %v", resp.Resources)
+	}
+
+	// Pages have the configured default size and tokens of the codec.
+	req := &pb.ListResourcesRequest{SortColumn: pb.ResourceSortColumn_RESOURCE_NAME}
+	resp, err = s.ListResources(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	if len(resp.Resources) != 2 || !strings.HasPrefix(resp.NextPageToken, "v1.") {
+		t.Fatalf("Expected 2 resources and a v1 token, got: %v", resp)
+	}
+	req.PageToken = resp.NextPageToken
+	if resp, err = s.ListResources(ctx, req); err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	if len(resp.Resources) != 1 || resp.Resources[0].Name != "c" {
+		t.Errorf("Expected the last resource, got: %v", resp.Resources)
+	}
+
+	// Tokens of another codec are rejected.
+	req.PageToken, _ = JSONCursorCodec{}.Encode(pageToken{Column: "name", Order: req.Order.String()})
+	_, err = s.ListResources(ctx, req)
+	if status.Code(statusError(err)) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a foreign token, got: %v", err)
+	}
+}
+
+// TestCRUDStatusCodes tests the status codes of invalid CRUD requests, as
+// translated by the error interceptors.
+func TestCRUDStatusCodes(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	resources := NewResourceServiceServer(Deps{DB: db})
+	rankings := NewAnimalRankingServiceServer(Deps{DB: db})
+
+	ar, err := rankings.CreateAnimalRanking(ctx, &pb.CreateAnimalRankingRequest{AnimalRanking: &pb.AnimalRanking{Rank: 1, Name: "Lion"}})
+	if err != nil {
+		t.Fatalf("Failed to create animal ranking: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"Get without a lookup", func() error {
+			_, err := resources.GetResource(ctx, &pb.GetResourceRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"Get an unknown resource", func() error {
+			_, err := resources.GetResource(ctx, &pb.GetResourceRequest{Lookup: &pb.GetResourceRequest_Id{Id: 42}})
+			return err
+		}, codes.NotFound},
+		{"Create without a name", func() error {
+			_, err := resources.CreateResource(ctx, &pb.CreateResourceRequest{Resource: &pb.Resource{}})
+			return err
+		}, codes.InvalidArgument},
+		{"Update an immutable field", func() error {
+			_, err := rankings.UpdateAnimalRanking(ctx, &pb.UpdateAnimalRankingRequest{
+				AnimalRanking: &pb.AnimalRanking{Id: ar.Id},
+				UpdateMask:    &fieldmaskpb.FieldMask{Paths: []string{"created_at"}},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"Update an unknown field", func() error {
+			_, err := rankings.UpdateAnimalRanking(ctx, &pb.UpdateAnimalRankingRequest{
+				AnimalRanking: &pb.AnimalRanking{Id: ar.Id},
+				UpdateMask:    &fieldmaskpb.FieldMask{Paths: []string{"color"}},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"Delete an unknown animal ranking", func() error {
+			_, err := rankings.DeleteAnimalRanking(ctx, &pb.DeleteAnimalRankingRequest{Id: 42})
+			return err
+		}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(statusError(tt.call())); code != tt.code {
+				t.Errorf("Expected %v, got: %v", tt.code, code)
+			}
+		})
+	}
+
+	// An empty mask updates every updatable field.
+	updated, err := rankings.UpdateAnimalRanking(ctx, &pb.UpdateAnimalRankingRequest{AnimalRanking: &pb.AnimalRanking{Id: ar.Id, Rank: 2, Name: "Tiger"}})
+	if err != nil {
+		t.Fatalf("Failed to update animal ranking: %v", err)
+	}
+	if updated.Rank != 2 || updated.Name != "Tiger" {
+		t.Errorf("Expected the rank and name to be updated, got: %v", updated)
+	}
+}
+
+// TestListAnimalRankingsByName tests paging through every animal ranking by
+// name with the page tokens, whose keys are names rather than ranks, and whose
+// ids order the rankings of the same name across pages.
+func TestListAnimalRankingsByName(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	s := NewAnimalRankingServiceServer(Deps{DB: db})
+	for i, name := range []string{"Lion", "Tiger", "Elephant", "Zebra", "Giraffe", "Cheetah", "Bear", "Elephant", "Elephant"} {
+		req := &pb.CreateAnimalRankingRequest{AnimalRanking: &pb.AnimalRanking{Rank: int32(i + 1), Name: name}}
+		if _, err := s.CreateAnimalRanking(ctx, req); err != nil {
+			t.Fatalf("Failed to create animal ranking: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		order    pb.SortOrder
+		expected []string
+	}{
+		{"Ascending", pb.SortOrder_ASC, []string{"Bear", "Cheetah", "Elephant", "Elephant", "Elephant", "Giraffe", "Lion", "Tiger", "Zebra"}},
+		{"Descending", pb.SortOrder_DESC, []string{"Zebra", "Tiger", "Lion", "Giraffe", "Elephant", "Elephant", "Elephant", "Cheetah", "Bear"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			token := ""
+			for pages := 0; ; pages++ {
+				if pages > len(tt.expected) {
+					t.Fatalf("Pagination did not terminate")
+				}
+				resp, err := s.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{
+					Limit:      3,
+					Order:      tt.order,
+					SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME,
+					PageToken:  token,
+				})
+				if err != nil {
+					t.Fatalf("Failed to list animal rankings: %v", err)
+				}
+				if len(resp.AnimalRankings) == 0 {
+					if resp.NextPageToken != "" {
+						t.Errorf("Expected no next page token for an empty page, got: %q", resp.NextPageToken)
+					}
+					break
+				}
+				for _, ar := range resp.AnimalRankings {
+					names = append(names, ar.Name)
+				}
+				token = resp.NextPageToken
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected names: %v, got: %v", tt.expected, names)
+			}
+		})
+	}
+
+	// A token issued for the name column cannot page by rank.
+	resp, err := s.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{Limit: 3, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_NAME})
+	if err != nil {
+		t.Fatalf("Failed to list animal rankings: %v", err)
+	}
+	_, err = s.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{Limit: 3, SortColumn: pb.AnimalRankingSortColumn_ANIMAL_RANK, PageToken: resp.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a token of another column, got: %v", err)
+	}
+}
+
+// TestListAnimalRankingsTimeRange tests the time range filters and the
+// timestamps of the listed animal rankings.
+func TestListAnimalRankingsTimeRange(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 25, 10, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Lion", "Tiger", "Elephant", "Zebra", "Giraffe"} {
+		created := start.Add(time.Duration(i) * time.Hour)
+		ar := &models.AnimalRanking{Rank: i + 1, Name: name, CreatedAt: created, UpdatedAt: created}
+		if err := ar.Insert(ctx, db); err != nil {
+			t.Fatalf("Failed to insert animal ranking: %v", err)
+		}
+	}
+	s := NewAnimalRankingServiceServer(Deps{DB: db})
+
+	resp, err := s.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{
+		Limit:         10,
+		CreatedAfter:  timestamppb.New(start),
+		CreatedBefore: timestamppb.New(start.Add(3 * time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to list animal rankings: %v", err)
+	}
+	var ranks []int32
+	for _, ar := range resp.AnimalRankings {
+		ranks = append(ranks, ar.Rank)
+	}
+	if !slices.Equal(ranks, []int32{2, 3}) {
+		t.Errorf("Expected ranks [2 3] created within the range, got: %v", ranks)
+	}
+	if len(resp.AnimalRankings) > 0 && !resp.AnimalRankings[0].CreateTime.AsTime().Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the create time of Tiger, got: %v", resp.AnimalRankings[0].CreateTime.AsTime())
+	}
+
+	_, err = s.ListAnimalRankings(ctx, &pb.ListAnimalRankingsRequest{
+		Limit:        10,
+		UpdatedAfter: &timestamppb.Timestamp{Nanos: -1},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid timestamp, got: %v", err)
+	}
+}
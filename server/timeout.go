@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// withQueryTimeout returns ctx bounded by timeout, unless timeout is 0. The
+// deadline of the call, carried by ctx, applies when it is sooner.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// deadlineError returns a DeadlineExceeded status for the error of a query run
+// past the deadline of ctx, as the drivers report aborted queries in their own
+// ways, and err otherwise.
+func deadlineError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "query timeout exceeded")
+	}
+	return err
+}
+
+// timeoutUnaryInterceptor returns the interceptor bounding the queries of the
+// unary calls by timeout.
+func timeoutUnaryInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := withQueryTimeout(ctx, timeout)
+		defer cancel()
+		resp, err := handler(ctx, req)
+		return resp, deadlineError(ctx, err)
+	}
+}
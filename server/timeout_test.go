@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/config"
+	pb "backend/proto"
+)
+
+// TestTimeoutUnaryInterceptor tests the deadlines of the queries of unary
+// calls, and the status of the calls running past them.
+func TestTimeoutUnaryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/backend.ResourceService/ListResources"}
+	// slow waits for the deadline, and fails like an interrupted query.
+	var deadline time.Time
+	slow := func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, _ = ctx.Deadline()
+		<-ctx.Done()
+		return nil, errors.New("interrupted")
+	}
+
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		deadline time.Duration
+		expected time.Duration
+	}{
+		{"Query timeout", 20 * time.Millisecond, 0, 20 * time.Millisecond},
+		{"Sooner call deadline", time.Minute, 20 * time.Millisecond, 20 * time.Millisecond},
+		{"No query timeout", 0, 20 * time.Millisecond, 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err := timeoutUnaryInterceptor(tt.timeout)(ctx, nil, info, slow)
+			if status.Code(err) != codes.DeadlineExceeded {
+				t.Errorf("Expected DeadlineExceeded, got: %v", err)
+			}
+			if d := deadline.Sub(start); d > tt.expected+10*time.Millisecond {
+				t.Errorf("Expected a deadline within %v, got: %v", tt.expected, d)
+			}
+		})
+	}
+
+	// Errors before the deadline are returned unchanged.
+	fail := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, invalidArgument("filter", "malformed")
+	}
+	_, err := timeoutUnaryInterceptor(time.Minute)(context.Background(), nil, info, fail)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got: %v", err)
+	}
+}
+
+// TestStreamQueryTimeout tests that the batches of the streams are bounded by
+// the query timeout.
+func TestStreamQueryTimeout(t *testing.T) {
+	cfg := config.Default()
+	cfg.Limits.QueryTimeout = time.Nanosecond
+	d := Deps{DB: seedTestDB(t), Config: cfg}
+
+	resources := newCancelingStream[pb.StreamResourcesResponse]()
+	err := NewResourceServiceServer(d).StreamResources(&pb.StreamResourcesRequest{}, resources)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded streaming resources, got: %v", err)
+	}
+
+	rankings := newCancelingStream[pb.StreamAnimalRankingsResponse]()
+	err = NewAnimalRankingServiceServer(d).StreamAnimalRankings(&pb.StreamAnimalRankingsRequest{}, rankings)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded streaming animal rankings, got: %v", err)
+	}
+}
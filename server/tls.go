@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is the minimum time between two checks of the certificate
+// files for changes.
+const certCheckInterval = 10 * time.Second
+
+// certReloader serves the certificate of a key pair, and the client CAs
+// verifying the client certificates, if any. It reloads the files when they
+// change, so that rotated certificates are served without a restart.
+type certReloader struct {
+	certFile, keyFile, clientCAFile string
+	// interval is the minimum time between two checks of the files.
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	checked   time.Time
+	modTimes  []time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// newCertReloader returns the reloader of the certificate and key of certFile
+// and keyFile, and the client CAs of clientCAFile if it is set.
+func newCertReloader(certFile, keyFile, clientCAFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		interval:     certCheckInterval,
+		now:          time.Now,
+	}
+	r.modTimes = r.stat()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.checked = r.now()
+	return r, nil
+}
+
+// files returns the names of the files of r.
+func (r *certReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// stat returns the modification times of the files, or the zero time for the
+// files that cannot be read.
+func (r *certReloader) stat() []time.Time {
+	var modTimes []time.Time
+	for _, name := range r.files() {
+		var modTime time.Time
+		if fi, err := os.Stat(name); err == nil {
+			modTime = fi.ModTime()
+		}
+		modTimes = append(modTimes, modTime)
+	}
+	return modTimes
+}
+
+// load reads the files. r.mu must be held, unless r is not yet shared.
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load client CAs: no certificate in %s", r.clientCAFile)
+		}
+	}
+	r.cert, r.clientCAs = &cert, clientCAs
+	return nil
+}
+
+// current returns the certificate and the client CAs, reloading them first
+// when the files changed since they were loaded. A failed reload is logged,
+// and the previous certificate is still served.
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.Sub(r.checked) < r.interval {
+		return r.cert, r.clientCAs
+	}
+	r.checked = now
+	modTimes := r.stat()
+	if !slices.EqualFunc(modTimes, r.modTimes, time.Time.Equal) {
+		// The files are retried on their next change only, as the files of a
+		// rotation may be written one after the other.
+		r.modTimes = modTimes
+		if err := r.load(); err != nil {
+			slog.Error("Failed to reload the TLS certificate", "err", err)
+		} else {
+			slog.Info("Reloaded the TLS certificate", "cert_file", r.certFile)
+		}
+	}
+	return r.cert, r.clientCAs
+}
+
+// serverConfig returns the TLS configuration of the gRPC server, serving the
+// current certificate. When client CAs are set, the clients may present a
+// certificate, which must be verified by them.
+func (r *certReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := r.current()
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				// The configuration replaces the one of the credentials,
+				// which negotiates HTTP/2.
+				NextProtos: []string{"h2"},
+			}
+			if clientCAs != nil {
+				c.ClientCAs = clientCAs
+				c.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			return c, nil
+		},
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"backend/config"
+	pb "backend/proto"
+)
+
+// testCA is a CA issuing the certificates of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	file string
+}
+
+// newTestCA returns a CA, written to ca.pem in dir.
+func newTestCA(t *testing.T, dir string) *testCA {
+	t.Helper()
+	ca := &testCA{cert: &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}}
+	der, key := ca.sign(t, ca.cert)
+	ca.cert, _ = x509.ParseCertificate(der)
+	ca.key = key
+	ca.pool = x509.NewCertPool()
+	ca.pool.AddCert(ca.cert)
+	ca.file = filepath.Join(dir, "ca.pem")
+	writeCertPEM(t, ca.file, "CERTIFICATE", der)
+	return ca
+}
+
+// sign returns the certificate of template, signed by the CA or self-signed
+// before it has a key, and its new key.
+func (ca *testCA) sign(t *testing.T, template *x509.Certificate) ([]byte, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parent, parentKey := ca.cert, ca.key
+	if parentKey == nil {
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return der, key
+}
+
+// issue writes the certificate of template and its key to name.pem and
+// name-key.pem in dir, and returns their file names.
+func (ca *testCA) issue(t *testing.T, dir, name string, template *x509.Certificate) (string, string) {
+	t.Helper()
+	der, key := ca.sign(t, template)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	writeCertPEM(t, certFile, "CERTIFICATE", der)
+	writeCertPEM(t, keyFile, "PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// writeCertPEM writes the PEM block of typ and der to the file name.
+func writeCertPEM(t *testing.T, name, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// serverCertTemplate is the template of the certificates of the server.
+func serverCertTemplate(name string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+// TestCertReloader tests that rotated certificates are served, and that a
+// failed reload keeps the previous certificate.
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server", serverCertTemplate("first"))
+	r, err := newCertReloader(certFile, keyFile, ca.file)
+	if err != nil {
+		t.Fatalf("Failed to load certificate: %v", err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	// touch bumps the modification time of the files, which may be written
+	// within the resolution of the file system.
+	mtime := now
+	touch := func() {
+		mtime = mtime.Add(time.Minute)
+		for _, name := range []string{certFile, keyFile} {
+			if err := os.Chtimes(name, mtime, mtime); err != nil {
+				t.Fatalf("Failed to touch %s: %v", name, err)
+			}
+		}
+	}
+	subject := func() string {
+		cert, clientCAs := r.current()
+		if clientCAs == nil {
+			t.Errorf("Expected the client CAs")
+		}
+		return cert.Leaf.Subject.CommonName
+	}
+
+	if got := subject(); got != "first" {
+		t.Errorf("Expected the first certificate, got: %s", got)
+	}
+	ca.issue(t, dir, "server", serverCertTemplate("second"))
+	touch()
+	now = now.Add(certCheckInterval)
+	if got := subject(); got != "second" {
+		t.Errorf("Expected the rotated certificate, got: %s", got)
+	}
+
+	// The files are checked at most once per interval.
+	ca.issue(t, dir, "server", serverCertTemplate("third"))
+	touch()
+	if got := subject(); got != "second" {
+		t.Errorf("Expected the certificate to be checked after the interval, got: %s", got)
+	}
+	now = now.Add(certCheckInterval)
+	if got := subject(); got != "third" {
+		t.Errorf("Expected the rotated certificate after the interval, got: %s", got)
+	}
+
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	touch()
+	now = now.Add(certCheckInterval)
+	if got := subject(); got != "third" {
+		t.Errorf("Expected the previous certificate after a failed reload, got: %s", got)
+	}
+}
+
+// TestMutualTLS tests that the client certificates verified by the client CAs
+// authenticate the clients, with the roles of their organizational units.
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	cfg := config.Default()
+	cfg.TLS.CertFile, cfg.TLS.KeyFile = ca.issue(t, dir, "server", serverCertTemplate("server"))
+	cfg.TLS.ClientCAFile = ca.file
+	srv, err := New(Deps{DB: initTestDB(t), Config: cfg})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go srv.grpc.Serve(listener)
+	t.Cleanup(srv.grpc.Stop)
+
+	// dial returns a client of the server presenting the certificate of the
+	// key pair files, if set.
+	dial := func(certFile, keyFile string) pb.ResourceServiceClient {
+		c := &tls.Config{RootCAs: ca.pool}
+		if certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				t.Fatalf("Failed to load client certificate: %v", err)
+			}
+			c.Certificates = []tls.Certificate{cert}
+		}
+		conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(c)))
+		if err != nil {
+			t.Fatalf("Failed to dial server: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewResourceServiceClient(conn)
+	}
+	reader := dial(ca.issue(t, dir, "reader", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "alice", OrganizationalUnit: []string{roleReader}, Organization: []string{"acme"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}))
+	anonymous := dial("", "")
+
+	ctx := context.Background()
+	if _, err := reader.ListResources(ctx, &pb.ListResourcesRequest{}); err != nil {
+		t.Errorf("Expected the reader to list resources, got: %v", err)
+	}
+	_, err = reader.CreateResource(ctx, &pb.CreateResourceRequest{Resource: &pb.Resource{Name: "Resource 1"}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for the reader to create, got: %v", err)
+	}
+	_, err = anonymous.ListResources(ctx, &pb.ListResourcesRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a client certificate, got: %v", err)
+	}
+
+	// A certificate of another CA is rejected by the handshake.
+	other := newTestCA(t, t.TempDir())
+	stranger := dial(other.issue(t, dir, "stranger", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "mallory", OrganizationalUnit: []string{roleWriter}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}))
+	if _, err := stranger.ListResources(ctx, &pb.ListResourcesRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for a certificate of another CA, got: %v", err)
+	}
+}
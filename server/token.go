@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/paginator"
+	pb "backend/proto"
+)
+
+// CursorCodec encodes the positions returned to the clients, the page tokens
+// and the change feed watermarks, as opaque strings.
+type CursorCodec interface {
+	// Encode returns the string of the position v.
+	Encode(v interface{}) (string, error)
+	// Decode decodes a string returned by Encode into v.
+	Decode(s string, v interface{}) error
+}
+
+// JSONCursorCodec encodes the positions as base64url JSON.
+type JSONCursorCodec struct{}
+
+// Encode satisfies the CursorCodec interface.
+func (JSONCursorCodec) Encode(v interface{}) (string, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Decode satisfies the CursorCodec interface.
+func (JSONCursorCodec) Decode(s string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// pageToken is the opaque position of a list page: the sort column and order
+// it was issued for, the key of the last record with the Go type of the sort
+// column, and its id breaking the ties of the key. Only one of the key fields
+// is set.
+type pageToken struct {
+	Column string     `json:"column"`
+	Order  string     `json:"order"`
+	Int    *int       `json:"int,omitempty"`
+	String *string    `json:"string,omitempty"`
+	Time   *time.Time `json:"time,omitempty"`
+	ID     *int       `json:"id,omitempty"`
+}
+
+// key returns the typed key of the token.
+func (t pageToken) key() interface{} {
+	switch {
+	case t.Int != nil:
+		return *t.Int
+	case t.String != nil:
+		return *t.String
+	case t.Time != nil:
+		return *t.Time
+	}
+	return nil
+}
+
+// decodePageToken decodes a page token encoded by codec, returning its key: the
+// cursor of the key and id of the last record, or the key alone for the tokens
+// issued without an id. The empty token is the nil key of the first page. A
+// token issued for another sort column or order is an invalid argument of the
+// request field.
+func decodePageToken(codec CursorCodec, field, token, column string, order pb.SortOrder) (interface{}, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var t pageToken
+	switch err := codec.Decode(token, &t); {
+	case err != nil:
+		return nil, invalidArgument(field, "malformed token")
+	case t.Column != column || t.Order != order.String():
+		return nil, invalidArgument(field, fmt.Sprintf("token was issued for %s %s", t.Column, t.Order))
+	case t.key() == nil:
+		return nil, invalidArgument(field, "token has no key")
+	case t.ID != nil:
+		return paginator.AfterRow(t.key(), *t.ID), nil
+	}
+	return t.key(), nil
+}
+
+// newPageToken returns the token of the position after the last record, of
+// the value key of the sort column and the id id.
+func newPageToken(column string, order pb.SortOrder, key interface{}, id int) (pageToken, error) {
+	t := pageToken{Column: column, Order: order.String(), ID: &id}
+	switch k := key.(type) {
+	case int:
+		t.Int = &k
+	case string:
+		t.String = &k
+	case time.Time:
+		t.Time = &k
+	default:
+		return t, fmt.Errorf("cannot page by %s of type %T", column, key)
+	}
+	return t, nil
+}
+
+// encodePageToken returns the page token of the position after the last
+// record, of the value key of the sort column and the id id, encoded by codec.
+func encodePageToken(codec CursorCodec, column string, order pb.SortOrder, key interface{}, id int) (string, error) {
+	t, err := newPageToken(column, order, key, id)
+	if err != nil {
+		return "", err
+	}
+	return codec.Encode(t)
+}
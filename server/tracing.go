@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"backend/sqlhook"
+)
+
+// serviceName is the name of the server in the traces.
+const serviceName = "grpc-server"
+
+// instrumentationName is the name of the tracer of the server.
+const instrumentationName = "backend/server"
+
+// dbRowsKey is the attribute of the number of rows read or affected by a
+// query.
+const dbRowsKey = attribute.Key("db.response.rows")
+
+// newSpanExporter returns the span exporter named by exporter: "otlp" exports
+// over OTLP/gRPC to endpoint, and "stdout" prints the spans, for local use.
+// It returns nil for "none" or an empty name.
+func newSpanExporter(ctx context.Context, exporter, endpoint string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(exporter) {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithInsecure()}
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+}
+
+// newTracerProvider returns a tracer provider batching the spans of the server
+// to exporter. Without an exporter, spans are recorded but not exported.
+func newTracerProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// setTracerProvider installs tp as the global tracer provider, with the W3C
+// trace context and baggage propagators.
+func setTracerProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// queryTraceHook traces the database queries in a span of the RPC running
+// them, with the SQL shape, the table, the number of rows and the error.
+func queryTraceHook(ctx context.Context, q sqlhook.Query) (context.Context, func(int, error)) {
+	name := strings.TrimSpace(q.Op + " " + q.Table)
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBQueryText(q.SQL),
+			semconv.DBOperationName(q.Op),
+			semconv.DBCollectionName(q.Table),
+		),
+	)
+	return ctx, func(rows int, err error) {
+		span.SetAttributes(dbRowsKey.Int(rows))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
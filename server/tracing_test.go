@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"backend/models"
+)
+
+// recordSpans installs a tracer provider recording the spans in memory for the
+// duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	setTracerProvider(tp)
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+// TestTracing tests that the queries of an RPC are traced in spans of its
+// server span, with the SQL shape, table and row count.
+func TestTracing(t *testing.T) {
+	db := initTestDB(t)
+	ctx := context.Background()
+	resources := []*models.Resource{{UUID: "uuid-1", Name: "Resource 1"}, {UUID: "uuid-2", Name: "Resource 2"}}
+	if err := models.InsertResourceBatch(ctx, db, resources); err != nil {
+		t.Fatalf("Failed to insert resources: %v", err)
+	}
+	exporter := recordSpans(t)
+	srv := startGateway(t, db)
+
+	resp, err := http.Get(srv.URL + "/v1/resources?page_size=10")
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	resp.Body.Close()
+
+	var server, query *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		switch {
+		case spans[i].SpanKind == trace.SpanKindServer && spans[i].Name == "backend.ResourceService/ListResources":
+			server = &spans[i]
+		case spans[i].Name == "SELECT resources":
+			query = &spans[i]
+		}
+	}
+	if server == nil || query == nil {
+		t.Fatalf("Expected the server and query spans, got: %v", spans.Snapshots())
+	}
+	if query.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("Expected the query span to be a child of the server span")
+	}
+	attrs := map[string]interface{}{}
+	for _, kv := range query.Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs["db.collection.name"] != "resources" || attrs["db.response.rows"] != int64(2) || attrs["db.query.text"] == "" {
+		t.Errorf("Expected the table, row count and SQL of the query, got: %v", attrs)
+	}
+
+	// Failing queries are recorded as errors.
+	exporter.Reset()
+	if _, err := db.QueryContext(ctx, "SELECT id FROM platform.missing"); err == nil {
+		t.Fatalf("Expected an error for an unknown table")
+	}
+	spans = exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error || spans[0].Name != "SELECT missing" {
+		t.Errorf("Expected an error span for the unknown table, got: %v", spans.Snapshots())
+	}
+}
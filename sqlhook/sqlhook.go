@@ -0,0 +1,321 @@
+// Package sqlhook wraps database/sql drivers to observe the queries they run.
+//
+// [Wrap] returns a connector whose connections call a [Hook] around every
+// query and statement, with the SQL shape, the operation and table parsed from
+// it, the number of rows read or affected, and the error. It is used for the
+// query metrics and traces of the server, and works with any driver, so the
+// queries of the generated models are observed without changing them.
+package sqlhook
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Query describes an observed query.
+type Query struct {
+	// SQL is the query as sent to the driver, with placeholders.
+	SQL string
+	// Op is the SQL operation: SELECT, INSERT, UPDATE, DELETE, ...
+	Op string
+	// Table is the first table of the query, without its schema.
+	Table string
+}
+
+// Hook is called when a query starts. It returns the context of the query and
+// a function called when it ends with the number of rows read or affected and
+// the error. The context is passed to the driver, so a hook can add values
+// such as a trace span.
+type Hook func(ctx context.Context, q Query) (context.Context, func(rows int, err error))
+
+// tableRE matches the first table of a query.
+var tableRE = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE|JOIN)\\s+[`\"\\[]?([A-Za-z0-9_.]+)")
+
+// Parse returns the description of query.
+func Parse(query string) Query {
+	q := Query{SQL: query}
+	if fields := strings.Fields(query); len(fields) != 0 {
+		q.Op = strings.ToUpper(fields[0])
+	}
+	if m := tableRE.FindStringSubmatch(query); m != nil {
+		q.Table = m[1][strings.LastIndex(m[1], ".")+1:]
+	}
+	return q
+}
+
+// Wrap returns a connector opening the connections of c, observing their
+// queries with hooks. Use it with [sql.OpenDB], or use [Open].
+func Wrap(c driver.Connector, hooks ...Hook) driver.Connector {
+	return &connector{Connector: c, hooks: hooks}
+}
+
+// Open opens a database with the named driver and data source, observing its
+// queries with hooks.
+func Open(driverName, dsn string, hooks ...Hook) (*sql.DB, error) {
+	// look up the driver
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+	var c driver.Connector = dsnConnector{dsn: dsn, driver: d}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(Wrap(c, hooks...)), nil
+}
+
+// dsnConnector is the connector of drivers that do not implement
+// driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+// Connect satisfies the driver.Connector interface.
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver satisfies the driver.Connector interface.
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connector is the connector returned by Wrap.
+type connector struct {
+	driver.Connector
+	hooks []Hook
+}
+
+// Connect satisfies the driver.Connector interface.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+// start calls the hooks for query, returning the context of the query and the
+// function ending it.
+func start(ctx context.Context, hooks []Hook, query string) (context.Context, func(rows int, err error)) {
+	q := Parse(query)
+	dones := make([]func(int, error), len(hooks))
+	for i, hook := range hooks {
+		ctx, dones[i] = hook(ctx, q)
+	}
+	return ctx, func(rows int, err error) {
+		// end in the reverse order
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](rows, err)
+		}
+	}
+}
+
+// result ends an observed statement with the rows affected by res.
+func result(res driver.Result, err error, done func(int, error)) (driver.Result, error) {
+	if err != nil {
+		done(0, err)
+		return nil, err
+	}
+	n, _ := res.RowsAffected()
+	done(int(n), nil)
+	return res, nil
+}
+
+// rowsOf ends an observed query when its rows are closed.
+func rowsOf(rows driver.Rows, err error, done func(int, error)) (driver.Rows, error) {
+	if err != nil {
+		done(0, err)
+		return nil, err
+	}
+	return &wrappedRows{Rows: rows, done: done}, nil
+}
+
+// wrappedConn observes the queries of a connection. It does not implement
+// driver.ExecerContext and driver.QueryerContext, so that database/sql runs
+// every query as a prepared statement, observed by wrappedStmt.
+type wrappedConn struct {
+	driver.Conn
+	hooks []Hook
+}
+
+// Prepare satisfies the driver.Conn interface.
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext satisfies the driver.ConnPrepareContext interface.
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		// observe the queries failing to prepare, such as syntax errors
+		_, done := start(ctx, c.hooks, query)
+		done(0, err)
+		return nil, err
+	}
+	return &wrappedStmt{Stmt: stmt, query: query, hooks: c.hooks}, nil
+}
+
+// BeginTx satisfies the driver.ConnBeginTx interface.
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping satisfies the driver.Pinger interface.
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession satisfies the driver.SessionResetter interface.
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid satisfies the driver.Validator interface.
+func (c *wrappedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue satisfies the driver.NamedValueChecker interface.
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// wrappedStmt observes the executions of a prepared statement.
+type wrappedStmt struct {
+	driver.Stmt
+	query string
+	hooks []Hook
+}
+
+// Exec satisfies the driver.Stmt interface.
+func (s *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, done := start(context.Background(), s.hooks, s.query)
+	res, err := s.Stmt.Exec(args)
+	return result(res, err, done)
+}
+
+// Query satisfies the driver.Stmt interface.
+func (s *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	_, done := start(context.Background(), s.hooks, s.query)
+	rows, err := s.Stmt.Query(args)
+	return rowsOf(rows, err, done)
+}
+
+// ExecContext satisfies the driver.StmtExecContext interface.
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, done := start(ctx, s.hooks, s.query)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err := e.ExecContext(ctx, args)
+		return result(res, err, done)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		done(0, err)
+		return nil, err
+	}
+	res, err := s.Stmt.Exec(values)
+	return result(res, err, done)
+}
+
+// QueryContext satisfies the driver.StmtQueryContext interface.
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, done := start(ctx, s.hooks, s.query)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err := q.QueryContext(ctx, args)
+		return rowsOf(rows, err, done)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		done(0, err)
+		return nil, err
+	}
+	rows, err := s.Stmt.Query(values)
+	return rowsOf(rows, err, done)
+}
+
+// CheckNamedValue satisfies the driver.NamedValueChecker interface.
+func (s *wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts the arguments of a statement for drivers without
+// context support, which do not support named arguments.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// errNamedArgs is returned for named arguments to drivers without context
+// support.
+var errNamedArgs = errors.New("sqlhook: driver does not support named arguments")
+
+// wrappedRows counts the rows read from a query, and ends it when closed.
+type wrappedRows struct {
+	driver.Rows
+	n    int
+	err  error
+	done func(int, error)
+}
+
+// Next satisfies the driver.Rows interface.
+func (r *wrappedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+// Close satisfies the driver.Rows interface.
+func (r *wrappedRows) Close() error {
+	err := r.Rows.Close()
+	if r.done != nil {
+		r.done(r.n, r.err)
+		r.done = nil
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package sqlhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestParse tests the operation and table parsed from queries.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		op    string
+		table string
+	}{
+		{"SELECT id, name FROM platform.resources WHERE id = ?", "SELECT", "resources"},
+		{"INSERT INTO platform.resources (uuid, name) VALUES (?, ?)", "INSERT", "resources"},
+		{"UPDATE platform.animal_rankings SET name = ? WHERE id = ?", "UPDATE", "animal_rankings"},
+		{"delete from `resources` where id = ?", "DELETE", "resources"},
+		{"SELECT \"id\" FROM (SELECT id FROM resources WHERE name LIKE ?) AS q", "SELECT", "resources"},
+		{"SELECT 1", "SELECT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q := Parse(tt.query)
+			if q.Op != tt.op || q.Table != tt.table {
+				t.Errorf("Expected %s on %q, got: %s on %q", tt.op, tt.table, q.Op, q.Table)
+			}
+		})
+	}
+}
+
+type observed struct {
+	Query
+	rows int
+	err  error
+}
+
+// TestOpen tests that the hook observes the queries and statements of a
+// database, with their rows and errors.
+func TestOpen(t *testing.T) {
+	var got []observed
+	hook := func(ctx context.Context, q Query) (context.Context, func(int, error)) {
+		return ctx, func(rows int, err error) {
+			got = append(got, observed{q, rows, err})
+		}
+	}
+	db, err := Open("sqlite3", ":memory:", hook)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO items (name) VALUES (?), (?), (?)`, "a", "b", "c"); err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT id, name FROM items WHERE id > ?`, 1)
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if err := db.QueryRowContext(ctx, `SELECT name FROM items WHERE id = ?`, 42).Scan(new(string)); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected no rows, got: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO missing (name) VALUES (?)`, "d"); err == nil {
+		t.Fatalf("Expected an error inserting into a missing table")
+	}
+
+	expected := []struct {
+		op, table string
+		rows      int
+	}{
+		{"CREATE", "", 0},
+		{"INSERT", "items", 3},
+		{"SELECT", "items", 2},
+		{"SELECT", "items", 0},
+		{"INSERT", "missing", 0},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d observed queries, got: %+v", len(expected), got)
+	}
+	for i, e := range expected {
+		if got[i].Op != e.op || got[i].Table != e.table || got[i].rows != e.rows || (got[i].err != nil) != (e.table == "missing") {
+			t.Errorf("Expected %s on %q with %d rows, got: %+v", e.op, e.table, e.rows, got[i])
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -150,6 +151,12 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Desc:       "uuid type package",
 				Default:    "github.com/google/uuid",
 			},
+			{
+				ContextKey: PaginatorKey,
+				Type:       "string",
+				Desc:       "keyset paginator package",
+				Default:    "backend/paginator",
+			},
 			{
 				ContextKey: CustomKey,
 				Type:       "string",
@@ -400,6 +407,11 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 			Data:     table,
 		})
 	}
+	// build keyset columns
+	keyset, tiebreak, err := buildQueryKeyset(query, table)
+	if err != nil {
+		return err
+	}
 	// build query params
 	var params []QueryParam
 	for _, param := range query.Params {
@@ -427,6 +439,8 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 			Interpolate: query.Interpolate,
 			Type:        table,
 			Comment:     query.Comment,
+			Keyset:      keyset,
+			Tiebreak:    tiebreak,
 		},
 	})
 	return nil
@@ -453,15 +467,67 @@ func buildQueryType(ctx context.Context, query xo.Query) (Table, error) {
 		}
 		fields = append(fields, f)
 	}
+	// a tenant_id result column scopes the generated queries and keyset pages
+	var tenant *Field
+	for i := range fields {
+		if fields[i].SQLName == tenantColumn {
+			tenant = &fields[i]
+		}
+	}
 	sqlName := snake(query.Type)
 	return Table{
 		GoName:  query.Type,
 		SQLName: sqlName,
 		Fields:  fields,
+		Tenant:  tenant,
 		Comment: query.TypeComment,
 	}, nil
 }
 
+// keysetRE matches the keyset directive in a custom query comment: the result
+// columns the query can be sorted on, followed by a unique result column
+// breaking the ties between equal values of the sort column, for example:
+//
+//	-- keyset: created_at, name, id
+var keysetRE = regexp.MustCompile(`(?i)^keyset:\s*(.+)$`)
+
+// buildQueryKeyset builds the sortable key columns and the unique tiebreak
+// column declared by a keyset directive of the query.
+func buildQueryKeyset(query xo.Query, table Table) ([]Field, *Field, error) {
+	var keyset []Field
+	var tiebreak *Field
+	for _, comment := range query.Comments {
+		comment = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "--"))
+		m := keysetRE.FindStringSubmatch(comment)
+		if m == nil {
+			continue
+		}
+		if query.Exec || query.Flat || query.One {
+			return nil, nil, fmt.Errorf("query %s: keyset requires a query returning multiple rows", buildQueryName(query))
+		}
+		var fields []Field
+		for _, name := range strings.Split(m[1], ",") {
+			name = strings.TrimSpace(name)
+			var found bool
+			for _, field := range table.Fields {
+				if field.SQLName == name || field.GoName == name {
+					fields, found = append(fields, field), true
+					break
+				}
+			}
+			if !found {
+				return nil, nil, fmt.Errorf("query %s: keyset column %q is not a result column", buildQueryName(query), name)
+			}
+		}
+		// the last column breaks the ties of the others
+		if len(fields) < 2 {
+			return nil, nil, fmt.Errorf("query %s: keyset %q requires a unique tiebreak column after the sort columns", buildQueryName(query), m[1])
+		}
+		keyset, tiebreak = fields[:len(fields)-1], &fields[len(fields)-1]
+	}
+	return keyset, tiebreak, nil
+}
+
 // buildQueryName builds a name for the query.
 func buildQueryName(query xo.Query) string {
 	if query.Name != "" {
@@ -554,7 +620,7 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 		}
 		// emit fkeys
 		for _, fk := range t.ForeignKeys {
-			fkey, err := convertFKey(ctx, table, fk)
+			fkey, err := convertFKey(ctx, table, fk, t.Indexes)
 			if err != nil {
 				return err
 			}
@@ -645,6 +711,7 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 // convertTable converts a xo.Table to a Table.
 func convertTable(ctx context.Context, t xo.Table) (Table, error) {
 	var cols, pkCols []Field
+	var softDelete, tenant, updatedAt *Field
 	for _, z := range t.Columns {
 		f, err := convertField(ctx, camelExport, z)
 		if err != nil {
@@ -654,17 +721,107 @@ func convertTable(ctx context.Context, t xo.Table) (Table, error) {
 		if z.IsPrimary {
 			pkCols = append(pkCols, f)
 		}
+		// a nullable deleted_at column marks the table as soft deleted
+		if z.Name == softDeleteColumn && f.Type == "sql.NullTime" {
+			softDelete = &f
+		}
+		// a tenant_id column makes the table tenant scoped
+		if z.Name == tenantColumn {
+			tenant = &f
+		}
+		// an updated_at timestamp, set by the database when the row changes,
+		// provides the table's change feed
+		if z.Name == updatedAtColumn && f.Type == "time.Time" {
+			updatedAt = &f
+		}
+	}
+	// soft delete requires a primary key to update
+	if len(pkCols) == 0 {
+		softDelete = nil
+	}
+	// the change feed is ordered by the updated_at and a single primary key
+	if len(pkCols) != 1 {
+		updatedAt = nil
+	}
+	sortKeys, err := indexSortKeys(ctx, t.Indexes)
+	if err != nil {
+		return Table{}, err
 	}
 	return Table{
 		GoName:      camelExport(singularize(t.Name)),
 		SQLName:     t.Name,
 		Fields:      cols,
 		PrimaryKeys: pkCols,
+		SoftDelete:  softDelete,
+		Tenant:      tenant,
+		UpdatedAt:   updatedAt,
+		SortKeys:    sortKeys,
 		Manual:      t.Manual,
 		Comment:     t.Definition,
 	}, nil
 }
 
+// indexSortKeys returns the leading fields of the indexes, once each, in the
+// order of the index names as the indexes are emitted.
+func indexSortKeys(ctx context.Context, indexes []xo.Index) ([]Field, error) {
+	indexes = append([]xo.Index(nil), indexes...)
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+	var keys []Field
+	seen := make(map[string]bool)
+	for _, i := range indexes {
+		if len(i.Fields) == 0 || seen[i.Fields[0].Name] {
+			continue
+		}
+		f, err := convertField(ctx, camelExport, i.Fields[0])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, f)
+		seen[f.SQLName] = true
+	}
+	return keys, nil
+}
+
+// filterOps returns the operators of the paginator conditions that apply to
+// the field: equality and IN for every field, the comparisons for the ordered
+// types and the NULL checks for the nullable fields.
+func filterOps(f Field) []string {
+	ops := []string{"=", "<>", "IN"}
+	switch f.Type {
+	case "bool", "sql.NullBool", "[]byte":
+	default:
+		ops = append(ops, "<", "<=", ">", ">=")
+	}
+	if f.Nullable {
+		ops = append(ops, "IS NULL", "IS NOT NULL")
+	}
+	return ops
+}
+
+// softDeleteColumn is the name of the column recognized as a soft delete
+// timestamp.
+const softDeleteColumn = "deleted_at"
+
+// tenantColumn is the name of the column recognized as the tenant of a row.
+const tenantColumn = "tenant_id"
+
+// updatedAtColumn is the name of the column recognized as the last change
+// timestamp of a row.
+const updatedAtColumn = "updated_at"
+
+// scopeKeys returns the fields identifying a row of the table in the WHERE
+// clause of updates and deletes: the primary keys, followed by the tenant
+// column for tenant scoped tables.
+func scopeKeys(t Table) []Field {
+	keys := t.PrimaryKeys
+	if t.Tenant != nil && !t.Tenant.IsPrimary {
+		keys = append(keys[:len(keys):len(keys)], *t.Tenant)
+	}
+	return keys
+}
+
 func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 	var fields []Field
 	for _, z := range i.Fields {
@@ -684,7 +841,7 @@ func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 	}, nil
 }
 
-func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, error) {
+func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey, indexes []xo.Index) (ForeignKey, error) {
 	var fields, refFields []Field
 	// convert fields
 	for _, f := range fk.Fields {
@@ -702,6 +859,19 @@ func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, er
 		}
 		refFields = append(refFields, refField)
 	}
+	sortKeys, err := fkeySortKeys(ctx, t, fk, indexes)
+	if err != nil {
+		return ForeignKey{}, err
+	}
+	// the children are only paged through an index on the foreign key
+	var pageFunc string
+	if len(sortKeys) != 0 {
+		pageFunc = inflector.Pluralize(t.GoName) + "By"
+		for _, f := range fields {
+			pageFunc += f.GoName
+		}
+		pageFunc += "Page"
+	}
 	return ForeignKey{
 		GoName:    camelExport(fk.Func),
 		SQLName:   fk.Name,
@@ -710,9 +880,60 @@ func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, er
 		RefTable:  camelExport(singularize(fk.RefTable)),
 		RefFields: refFields,
 		RefFunc:   camelExport(fk.RefFunc),
+		SortKeys:  sortKeys,
+		PageFunc:  pageFunc,
 	}, nil
 }
 
+// fkeySortKeys returns the fields that the children of the foreign key can be
+// sorted on through an index, once each, in the order of the index names: the
+// field following the foreign key fields in an index starting with them, or
+// the single primary key of the table for an index on exactly the foreign key
+// fields, as InnoDB appends the primary key to secondary indexes.
+func fkeySortKeys(ctx context.Context, t Table, fk xo.ForeignKey, indexes []xo.Index) ([]Field, error) {
+	indexes = append([]xo.Index(nil), indexes...)
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+	var keys []Field
+	seen := make(map[string]bool)
+	for _, i := range indexes {
+		if !fieldsPrefix(i.Fields, fk.Fields) {
+			continue
+		}
+		var f Field
+		switch {
+		case len(i.Fields) > len(fk.Fields):
+			var err error
+			if f, err = convertField(ctx, camelExport, i.Fields[len(fk.Fields)]); err != nil {
+				return nil, err
+			}
+		case len(t.PrimaryKeys) == 1:
+			f = t.PrimaryKeys[0]
+		default:
+			continue
+		}
+		if !seen[f.SQLName] {
+			keys = append(keys, f)
+			seen[f.SQLName] = true
+		}
+	}
+	return keys, nil
+}
+
+// fieldsPrefix returns true when the fields start with the prefix fields.
+func fieldsPrefix(fields, prefix []xo.Field) bool {
+	if len(fields) < len(prefix) {
+		return false
+	}
+	for i, f := range prefix {
+		if fields[i].Name != f.Name {
+			return false
+		}
+	}
+	return true
+}
+
 func overloadedName(sqlTypes []string, proc Proc) string {
 	if len(proc.Params) == 0 {
 		return proc.GoName
@@ -743,7 +964,9 @@ func convertField(ctx context.Context, tf transformFunc, f xo.Field) (Field, err
 		Type:       typ,
 		GoName:     tf(f.Name),
 		SQLName:    f.Name,
+		SQLType:    f.Type.Type,
 		Zero:       zero,
+		Nullable:   f.Type.Nullable,
 		IsPrimary:  f.IsPrimary,
 		IsSequence: f.IsSequence,
 		Comment:    f.Comment,
@@ -891,22 +1114,31 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"db_prefix":           f.db_prefix,
 		"db_update":           f.db_update,
 		"db_named":            f.db_named,
+		"db_select":           f.db_select,
 		"named":               f.named,
 		"logf":                f.logf,
 		"logf_pkeys":          f.logf_pkeys,
 		"logf_update":         f.logf_update,
+		"deleted_clause":      f.deleted_clause,
+		"tenant_from":         f.tenant_from,
+		"scope_tenant":        f.scope_tenant,
 		// type
 		"names":        f.names,
 		"names_all":    f.names_all,
 		"names_ignore": f.names_ignore,
+		"scope_keys":   scopeKeys,
+		"batch_fields": batchFields,
+		"filter_ops":   filterOps,
 		"params":       f.params,
+		"param":        f.param,
 		"zero":         f.zero,
 		"type":         f.typefn,
 		"field":        f.field,
 		"short":        f.short,
 		// sqlstr funcs
-		"querystr": f.querystr,
-		"sqlstr":   f.sqlstr,
+		"querystr":        f.querystr,
+		"querystr_tenant": f.querystr_tenant,
+		"sqlstr":          f.sqlstr,
 		// helpers
 		"check_name": checkName,
 		"eval":       eval,
@@ -1192,6 +1424,16 @@ func (f *Funcs) db(name string, v ...interface{}) string {
 	return fmt.Sprintf("db.%s(%s)", name, f.names("", append(p, v...)...))
 }
 
+// db_select generates a select<name>(ctx, db, sqlstr, ...), which runs the
+// SELECT through a logQueryer.
+func (f *Funcs) db_select(name string, v ...interface{}) string {
+	ctx := "context.Background()"
+	if f.contextfn() {
+		ctx = "ctx"
+	}
+	return fmt.Sprintf("select%s(%s)", name, f.names("", append([]interface{}{ctx, "db", "sqlstr"}, v...)...))
+}
+
 // db_prefix generates a db.<name>Context(ctx, sqlstr, <prefix>.param, ...).
 //
 // Will skip the specific parameters based on the type provided.
@@ -1236,7 +1478,10 @@ func (f *Funcs) db_update(name string, v interface{}) string {
 		for _, pk := range x.PrimaryKeys {
 			ignore = append(ignore, pk.GoName)
 		}
-		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, x.PrimaryKeys))
+		if x.UpdatedAt != nil {
+			ignore = append(ignore, x.UpdatedAt.GoName)
+		}
+		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, scopeKeys(x)))
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 9: %T ]]", v)
 	}
@@ -1274,7 +1519,7 @@ func (f *Funcs) logf_pkeys(v interface{}) string {
 	p := []string{"sqlstr"}
 	switch x := v.(type) {
 	case Table:
-		p = append(p, f.names(f.short(x.GoName)+".", x.PrimaryKeys))
+		p = append(p, f.names(f.short(x.GoName)+".", scopeKeys(x)))
 	}
 	return fmt.Sprintf("logf(%s)", strings.Join(p, ", "))
 }
@@ -1316,7 +1561,10 @@ func (f *Funcs) logf_update(v interface{}) string {
 		for _, pk := range x.PrimaryKeys {
 			ignore = append(ignore, pk.GoName)
 		}
-		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, x.PrimaryKeys))
+		if x.UpdatedAt != nil {
+			ignore = append(ignore, x.UpdatedAt.GoName)
+		}
+		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, scopeKeys(x)))
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 13: %T ]]", v)
 	}
@@ -1400,6 +1648,33 @@ func (f *Funcs) names_ignore(prefix string, v interface{}, ignore ...string) str
 // querystr generates a querystr for the specified query and any accompanying
 // comments.
 func (f *Funcs) querystr(v interface{}) string {
+	return f.querystr_named("sqlstr", v)
+}
+
+// querystr_tenant generates the sqlstr of a custom query returning a tenant
+// column, restricted to the tenant passed after the query's params.
+func (f *Funcs) querystr_tenant(v interface{}) string {
+	x, ok := v.(Query)
+	if !ok || x.Type.Tenant == nil {
+		return fmt.Sprintf("const sqlstr = [[ UNSUPPORTED TYPE 34: %T ]]", v)
+	}
+	var n int
+	for _, p := range x.Params {
+		if !p.Interpolate {
+			n++
+		}
+	}
+	typ := "const"
+	if x.Interpolate {
+		typ = "var"
+	}
+	return fmt.Sprintf("%s\n\t%s sqlstr = `SELECT * FROM (` + unscoped + `) AS q WHERE %s = %s`",
+		f.querystr_named("unscoped", v), typ, f.colname(*x.Type.Tenant), f.nth(n))
+}
+
+// querystr_named generates the declaration of the query string name of a
+// custom query.
+func (f *Funcs) querystr_named(name string, v interface{}) string {
 	var interpolate bool
 	var query, comments []string
 	switch x := v.(type) {
@@ -1424,7 +1699,7 @@ func (f *Funcs) querystr(v interface{}) string {
 		lines = append(lines, line)
 	}
 	sqlstr := stripRE.ReplaceAllString(strings.Join(lines, "\n"), " ")
-	return fmt.Sprintf("%s sqlstr = %s", typ, sqlstr)
+	return fmt.Sprintf("%s %s = %s", typ, name, sqlstr)
 }
 
 var stripRE = regexp.MustCompile(`\s+\+\s+` + "``")
@@ -1442,10 +1717,26 @@ func (f *Funcs) sqlstr(typ string, v interface{}) string {
 		lines = f.sqlstr_upsert(v)
 	case "delete":
 		lines = f.sqlstr_delete(v)
+	case "soft_delete":
+		lines = f.sqlstr_soft_delete(v)
+	case "insert_batch":
+		lines = f.sqlstr_insert_batch(false, v)
+	case "upsert_batch":
+		lines = f.sqlstr_insert_batch(true, v)
+	case "changes":
+		lines = f.sqlstr_changes(v)
+	case "touch":
+		lines = f.sqlstr_touch(v)
+	case "upsert_conflict":
+		return fmt.Sprintf("const conflict = `%s`", strings.Join(f.sqlstr_upsert_conflict(v), "` +\n\t`"))
 	case "proc":
 		lines = f.sqlstr_proc(v)
 	case "index":
 		lines = f.sqlstr_index(v)
+		// exclude soft deleted rows according to the context's scope
+		if x, ok := v.(Index); ok && x.Table.SoftDelete != nil {
+			return fmt.Sprintf("sqlstr := `%s` +\n\t%s", strings.Join(lines, "` +\n\t`"), f.deleted_clause(x.Table))
+		}
 	default:
 		return fmt.Sprintf("const sqlstr = `UNKNOWN QUERY TYPE: %s`", typ)
 	}
@@ -1519,6 +1810,52 @@ func (f *Funcs) sqlstr_insert(v interface{}) []string {
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 18: %T ]]", v)}
 }
 
+// batchFields returns the fields of the table set by a multi-row insert,
+// skipping sequence fields unless all.
+func batchFields(t Table, all bool) []Field {
+	var fields []Field
+	for _, z := range t.Fields {
+		if z.IsSequence && !all {
+			continue
+		}
+		fields = append(fields, z)
+	}
+	return fields
+}
+
+// sqlstr_insert_batch builds the start of a multi-row INSERT query, up to the
+// VALUES keyword. The generated code appends the row placeholders.
+func (f *Funcs) sqlstr_insert_batch(all bool, v interface{}) []string {
+	switch x := v.(type) {
+	case Table:
+		var fields []string
+		for _, z := range batchFields(x, all || x.Manual) {
+			fields = append(fields, f.colname(z))
+		}
+		return []string{
+			"INSERT INTO " + f.schemafn(x.SQLName) + " (",
+			strings.Join(fields, ", "),
+			") VALUES ",
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 34: %T ]]", v)}
+}
+
+// sqlstr_upsert_conflict builds the conflict clause appended to a multi-row
+// upsert query.
+func (f *Funcs) sqlstr_upsert_conflict(v interface{}) []string {
+	switch x := v.(type) {
+	case Table:
+		switch f.driver {
+		case "postgres", "sqlite3":
+			return f.sqlstr_upsert_postgres_sqlite(x)
+		case "mysql":
+			return f.sqlstr_upsert_mysql(x)
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 35 %s: %T ]]", f.driver, v)}
+}
+
 // sqlstr_update_base builds an UPDATE query, using primary key fields as the WHERE
 // clause, adding prefix.
 //
@@ -1537,6 +1874,10 @@ func (f *Funcs) sqlstr_update_base(prefix string, v interface{}) (int, []string)
 			if z.IsPrimary {
 				continue
 			}
+			// the database sets updated_at when the row changes
+			if prefix == "" && x.UpdatedAt != nil && z.SQLName == x.UpdatedAt.SQLName {
+				continue
+			}
 			name, param := f.colname(z), f.nth(n)
 			if prefix != "" {
 				param = prefix + name
@@ -1564,7 +1905,7 @@ func (f *Funcs) sqlstr_update(v interface{}) []string {
 	case Table:
 		var list []string
 		n, lines := f.sqlstr_update_base("", v)
-		for i, z := range x.PrimaryKeys {
+		for i, z := range scopeKeys(x) {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(n+i)))
 		}
 		return append(lines, "WHERE "+strings.Join(list, " AND "))
@@ -1602,6 +1943,11 @@ func (f *Funcs) sqlstr_upsert_postgres_sqlite(v interface{}) []string {
 		}
 		lines := []string{" ON CONFLICT (" + strings.Join(conflicts, ", ") + ") DO "}
 		_, update := f.sqlstr_update_base("EXCLUDED.", v)
+		// never update a row belonging to another tenant
+		if x.Tenant != nil {
+			name := f.colname(*x.Tenant)
+			update = append(update, fmt.Sprintf("WHERE %s.%s = EXCLUDED.%s", x.SQLName, name, name))
+		}
 		return append(lines, update...)
 	}
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 22: %T ]]", v)}
@@ -1621,7 +1967,16 @@ func (f *Funcs) sqlstr_upsert_mysql(v interface{}) []string {
 				continue
 			}
 			name := f.colname(z)
-			list = append(list, fmt.Sprintf("%s = VALUES(%s)", name, name))
+			switch {
+			case x.Tenant == nil:
+				list = append(list, fmt.Sprintf("%s = VALUES(%s)", name, name))
+			case z.SQLName == x.Tenant.SQLName:
+				// the tenant of an existing row never changes
+			default:
+				// leave rows belonging to another tenant unchanged
+				tenant := f.colname(*x.Tenant)
+				list = append(list, fmt.Sprintf("%s = IF(%s = VALUES(%s), VALUES(%s), %s)", name, tenant, tenant, name, name))
+			}
 			i++
 		}
 		return append(lines, strings.Join(list, ", "))
@@ -1648,7 +2003,7 @@ func (f *Funcs) sqlstr_upsert_sqlserver_oracle(v interface{}) []string {
 		for i, field := range x.Fields {
 			fields = append(fields, fmt.Sprintf("%s %s", f.nth(i), field.SQLName))
 		}
-		for _, field := range x.PrimaryKeys {
+		for _, field := range scopeKeys(x) {
 			predicate = append(predicate, fmt.Sprintf("s.%s = t.%s", field.SQLName, field.SQLName))
 		}
 		// closing part for select
@@ -1699,7 +2054,7 @@ func (f *Funcs) sqlstr_delete(v interface{}) []string {
 	case Table:
 		// names and values
 		var list []string
-		for i, z := range x.PrimaryKeys {
+		for i, z := range scopeKeys(x) {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
 		}
 		return []string{
@@ -1710,6 +2065,124 @@ func (f *Funcs) sqlstr_delete(v interface{}) []string {
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 25: %T ]]", v)}
 }
 
+// sqlstr_soft_delete builds an UPDATE query setting the soft delete column
+// for the primary keys.
+func (f *Funcs) sqlstr_soft_delete(v interface{}) []string {
+	switch x := v.(type) {
+	case Table:
+		if x.SoftDelete == nil {
+			break
+		}
+		// names and values
+		var list []string
+		for i, z := range scopeKeys(x) {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(1+i)))
+		}
+		return []string{
+			"UPDATE " + f.schemafn(x.SQLName) + " SET ",
+			f.colname(*x.SoftDelete) + " = " + f.nth(0) + " ",
+			"WHERE " + strings.Join(list, " AND "),
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 31: %T ]]", v)}
+}
+
+// sqlstr_touch builds a SELECT query reading the updated_at column set by the
+// database, using the primary key fields as the WHERE clause.
+func (f *Funcs) sqlstr_touch(v interface{}) []string {
+	switch x := v.(type) {
+	case Table:
+		if x.UpdatedAt == nil {
+			break
+		}
+		var list []string
+		for i, z := range scopeKeys(x) {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
+		}
+		return []string{
+			"SELECT " + f.colname(*x.UpdatedAt) + " ",
+			"FROM " + f.schemafn(x.SQLName) + " ",
+			"WHERE " + strings.Join(list, " AND "),
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 37: %T ]]", v)}
+}
+
+// deleted_clause generates a call restricting the soft delete column of the
+// table to the scope carried by the context.
+func (f *Funcs) deleted_clause(v interface{}) string {
+	switch x := v.(type) {
+	case Table:
+		if x.SoftDelete == nil {
+			return `""`
+		}
+		ctx := "ctx"
+		if !f.contextfn() {
+			ctx = "context.Background()"
+		}
+		return fmt.Sprintf("deletedClause(%s, %q)", ctx, f.colname(*x.SoftDelete))
+	}
+	return fmt.Sprintf("[[ UNSUPPORTED TYPE 32: %T ]]", v)
+}
+
+// tenant_from generates a call retrieving the tenant carried by the context.
+func (f *Funcs) tenant_from() string {
+	if !f.contextfn() {
+		return "tenantFrom(context.Background())"
+	}
+	return "tenantFrom(ctx)"
+}
+
+// scope_tenant generates a call scoping the tenant field of the table's
+// receiver to the tenant carried by the context.
+func (f *Funcs) scope_tenant(v interface{}) string {
+	switch x := v.(type) {
+	case Table:
+		if x.Tenant == nil {
+			break
+		}
+		ctx := "ctx"
+		if !f.contextfn() {
+			ctx = "context.Background()"
+		}
+		return fmt.Sprintf("scopeTenant(%s, &%s.%s)", ctx, f.short(x), x.Tenant.GoName)
+	}
+	return fmt.Sprintf("[[ UNSUPPORTED TYPE 33: %T ]]", v)
+}
+
+// sqlstr_changes builds a query selecting the rows changed after a
+// (updated_at, primary key) watermark, in change order.
+func (f *Funcs) sqlstr_changes(v interface{}) []string {
+	switch x := v.(type) {
+	case Table:
+		if x.UpdatedAt == nil {
+			break
+		}
+		// build table fieldnames
+		var fields []string
+		for _, z := range x.Fields {
+			fields = append(fields, f.colname(z))
+		}
+		updatedAt, pk := f.colname(*x.UpdatedAt), f.colname(x.PrimaryKeys[0])
+		where := fmt.Sprintf("WHERE (%s > %s OR (%s = %s AND %s > %s)) ", updatedAt, f.nth(0), updatedAt, f.nth(1), pk, f.nth(2))
+		n := 3
+		// scope to the tenant, passed after the watermark
+		if x.Tenant != nil {
+			where += fmt.Sprintf("AND %s = %s ", f.colname(*x.Tenant), f.nth(n))
+			n++
+		}
+		return []string{
+			"SELECT ",
+			strings.Join(fields, ", ") + " ",
+			"FROM " + f.schemafn(x.SQLName) + " ",
+			where,
+			fmt.Sprintf("ORDER BY %s, %s ", updatedAt, pk),
+			"LIMIT " + f.nth(n),
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 36: %T ]]", v)}
+}
+
 // sqlstr_index builds a index fields.
 func (f *Funcs) sqlstr_index(v interface{}) []string {
 	switch x := v.(type) {
@@ -1724,6 +2197,10 @@ func (f *Funcs) sqlstr_index(v interface{}) []string {
 		for i, z := range x.Fields {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
 		}
+		// scope to the tenant, passed after the index fields
+		if x.Table.Tenant != nil {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(*x.Table.Tenant), f.nth(len(x.Fields))))
+		}
 		return []string{
 			"SELECT ",
 			strings.Join(fields, ", ") + " ",
@@ -2101,6 +2578,7 @@ var (
 	TagKey        xo.ContextKey = "tag"
 	ImportKey     xo.ContextKey = "import"
 	UUIDKey       xo.ContextKey = "uuid"
+	PaginatorKey  xo.ContextKey = "paginator"
 	CustomKey     xo.ContextKey = "custom"
 	ConflictKey   xo.ContextKey = "conflict"
 	InitialismKey xo.ContextKey = "initialism"
@@ -2191,6 +2669,10 @@ func Imports(ctx context.Context) []string {
 	if s, _ := ctx.Value(UUIDKey).(string); s != "" {
 		imports = append(imports, s)
 	}
+	// add paginator import
+	if s, _ := ctx.Value(PaginatorKey).(string); s != "" {
+		imports = append(imports, s)
+	}
 	return imports
 }
 
@@ -2315,8 +2797,14 @@ type Table struct {
 	SQLName     string
 	PrimaryKeys []Field
 	Fields      []Field
-	Manual      bool
-	Comment     string
+	SoftDelete  *Field
+	Tenant      *Field
+	UpdatedAt   *Field
+	// SortKeys are the leading fields of the indexes of the table, which
+	// keyset pages can seek and sort on without scanning the table.
+	SortKeys []Field
+	Manual   bool
+	Comment  string
 }
 
 // ForeignKey is a foreign key template.
@@ -2328,7 +2816,11 @@ type ForeignKey struct {
 	RefTable  string
 	RefFields []Field
 	RefFunc   string
-	Comment   string
+	// SortKeys are the fields following the foreign key fields in its indexes,
+	// which the children of a parent can be paged on.
+	SortKeys []Field
+	PageFunc string
+	Comment  string
 }
 
 // Index is an index template.
@@ -2347,7 +2839,9 @@ type Field struct {
 	GoName     string
 	SQLName    string
 	Type       string
+	SQLType    string
 	Zero       string
+	Nullable   bool
 	IsPrimary  bool
 	IsSequence bool
 	Comment    string
@@ -2373,6 +2867,10 @@ type Query struct {
 	Interpolate bool
 	Type        Table
 	Comment     string
+	Keyset      []Field
+	// Tiebreak is the unique result column ordering the results with equal
+	// values of the sort column.
+	Tiebreak *Field
 }
 
 // PackageImport holds information about a Go package import.
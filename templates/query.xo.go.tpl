@@ -6,28 +6,43 @@
 // {{ func_name_context $q }} runs a custom query{{ if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
 {{- end }}
 {{ func_context $q }} {
+{{- if $q.Type.Tenant }}
+	// query, restricted to the context's tenant
+	{{ querystr_tenant $q }}
+	tenant, err := {{ tenant_from }}
+	if err != nil {
+		return {{ if $q.Flat }}{{ zero $q.Type.Fields "logerror(err)" }}{{ else }}nil, logerror(err){{ end }}
+	}
+	// run
+{{- if $q.Exec }}
+	logf({{ names "" "sqlstr" $q "tenant" }})
+{{- end }}
+{{- else }}
 	// query
 	{{ querystr $q }}
 	// run
+{{- if $q.Exec }}
 	logf({{ names "" "sqlstr" $q }})
+{{- end }}
+{{- end }}
 {{ if $q.Exec -}}
 	return {{ db "Exec" $q }}
 {{- else if $q.Flat -}}
 {{- range $q.Type.Fields -}}
 	var {{ .GoName }} {{ type .Type }}
 {{ end -}}
-	if err := {{ db "QueryRow" $q }}.Scan({{ names "&" $q.Type.Fields }}); err != nil {
+	if err := {{ if $q.Type.Tenant }}{{ db_select "Row" $q "tenant" }}{{ else }}{{ db_select "Row" $q }}{{ end }}.Scan({{ names "&" $q.Type.Fields }}); err != nil {
 		return {{ zero $q.Type.Fields "logerror(err)" }}
 	}
 	return {{ names "" $q.Type "nil" }}
 {{- else if $q.One -}}
 	var {{ short $q.Type }} {{ type $q.Type.GoName }}
-	if err := {{ db "QueryRow" $q }}.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
+	if err := {{ if $q.Type.Tenant }}{{ db_select "Row" $q "tenant" }}{{ else }}{{ db_select "Row" $q }}{{ end }}.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
 		return nil, logerror(err)
 	}
 	return &{{ short $q.Type }}, nil
 {{- else -}}
-	rows, err := {{ db "Query" $q }}
+	rows, err := {{ if $q.Type.Tenant }}{{ db_select "Rows" $q "tenant" }}{{ else }}{{ db_select "Rows" $q }}{{ end }}
 	if err != nil {
 		return nil, logerror(err)
 	}
@@ -59,6 +74,59 @@
 	return {{ func_name_context $q }}({{ names_all "" "context.Background()" "db" $q }})
 }
 {{- end }}
+{{- if $q.Keyset }}
+
+// {{ func_name_context $q }}KeysetPage runs the custom query as a subquery, returning a page of results as [{{ $q.Type.GoName }}] using keyset pagination with dynamic filtering.
+//
+// The keyset pagination retrieves results after or before a specific value (`key`)
+// for a given column (`column`) with a limit (`limit`) and order (`ASC` or `DESC`).
+// The column must be one of the keyset columns declared by the query: {{ range $i, $f := $q.Keyset }}{{ if $i }}, {{ end }}`{{ $f.SQLName }}`{{ end }}.
+// A nil `key` retrieves the first page.
+// Results with equal values of `column` are ordered by `{{ $q.Tiebreak.SQLName }}`: a `key` made by
+// [paginator.AfterRow] from the values of `column` and `{{ $q.Tiebreak.SQLName }}` of the last result
+// resumes after it without skipping the results equal to it.
+//
+// Filters are dynamically provided via a `filters` map, where keys are result column names and values are either single values or slices for `IN` clauses.
+// Typed conditions such as time ranges on the result columns are provided via `where`.
+func {{ func_name_context $q }}KeysetPage(ctx context.Context, db DB{{ range $q.Params }}, {{ .Name }} {{ .Type }}{{ end }}, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]*{{ type $q.Type.GoName }}, *{{ type $q.Type.GoName }}, error) {
+	// query
+	{{ querystr $q }}
+	// Wrap the custom query so the keyset and filters apply to its result columns
+	q := paginator.Query{
+		From:     "(" + sqlstr + ") AS q",
+		FromArgs: []interface{}{ {{- names "" $q -}} },
+		Columns:  []string{ {{- range $i, $f := $q.Type.Fields }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+		Keys:     []string{ {{- range $i, $f := $q.Keyset }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+		Where:    where,
+		Sort:     paginator.SortKey{Column: column, Order: paginator.Order(order)},
+		Tiebreak: "{{ $q.Tiebreak.SQLName }}",
+		Filters:  filters,
+		Limit:    limit,
+	}
+{{- if $q.Type.Tenant }}
+	// Scope the query to the context's tenant
+	tenant, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	q.Where = append(q.Where, paginator.Eq("{{ $q.Type.Tenant.SQLName }}", tenant))
+{{- end }}
+	// run
+	page, err := paginator.Fetch(ctx, logQueryer{db}, dialect, q, paginator.After(key), paginator.Mapper[*{{ type $q.Type.GoName }}]{
+		Scan: func(s paginator.Scanner) (*{{ type $q.Type.GoName }}, error) {
+			var {{ short $q.Type }} {{ type $q.Type.GoName }}
+			err := s.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }})
+			return &{{ short $q.Type }}, err
+		},
+	})
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	// the last result is the key for the next page
+	last, _ := page.Last()
+	return page.Items, last, nil
+}
+{{- end }}
 {{ end }}
 
 {{ define "typedef" }}
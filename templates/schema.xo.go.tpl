@@ -112,6 +112,66 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 	return {{ foreign_key $k }}
 }
 {{- end }}
+{{- if $k.PageFunc }}
+
+// {{ $k.PageFunc }} retrieves a page of the [{{ $k.Table.GoName }}] records belonging to the {{ $k.RefTable }} identified by ({{ names "" $k.RefFields }}).
+//
+// The page is read in the order of an index on ({{ range $k.Fields }}{{ .SQLName }}, {{ end }}`column`): the foreign key
+// is fixed by equality and `column` must be one of the columns following it in an index
+// ({{ range $i, $f := $k.SortKeys }}{{ if $i }}, {{ end }}{{ $f.SQLName }}{{ end }}){{ if eq (len $k.Table.PrimaryKeys) 1 }}, with the records of equal values ordered by `{{ (index $k.Table.PrimaryKeys 0).SQLName }}`{{ end }}. The keyset (`key`, `order`),
+// the limit, the `filters` map and the `where` conditions behave as in [{{ $k.Table.GoName }}KeysetPage].
+//
+// Generated from foreign key '{{ $k.SQLName }}'.
+func {{ $k.PageFunc }}(ctx context.Context, db DB, {{ params $k.Fields true }}, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]*{{ $k.Table.GoName }}, *{{ $k.Table.GoName }}, error) {
+	q := paginator.Query{
+		From:    "{{ $k.Table.SQLName }}",
+		Columns: []string{ {{- range $i, $f := $k.Table.Fields }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+		Keys:    []string{ {{- range $i, $f := $k.SortKeys }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+		// Scope the query to the parent
+		Where: []paginator.Condition{
+{{- range $k.Fields }}
+			paginator.Eq("{{ .SQLName }}", {{ param . false }}),
+{{- end }}
+		},
+		Sort:    paginator.SortKey{Column: column, Order: paginator.Order(order)},
+{{- if eq (len $k.Table.PrimaryKeys) 1 }}
+		Tiebreak: "{{ (index $k.Table.PrimaryKeys 0).SQLName }}",
+{{- end }}
+		Filters: filters,
+		Limit:   limit,
+	}
+{{- if $k.Table.SoftDelete }}
+	// Exclude soft deleted rows according to the context's scope
+	q.Where = append(q.Where, deletedConditions(ctx, "{{ $k.Table.SoftDelete.SQLName }}")...)
+{{- end }}
+	q.Where = append(q.Where, where...)
+{{- if $k.Table.Tenant }}
+	// Scope the query to the context's tenant
+	tenant, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	q.Where = append(q.Where, paginator.Eq("{{ $k.Table.Tenant.SQLName }}", tenant))
+{{- end }}
+	page, err := paginator.Fetch(ctx, logQueryer{db}, dialect, q, paginator.After(key), paginator.Mapper[*{{ $k.Table.GoName }}]{
+		Scan: func(s paginator.Scanner) (*{{ $k.Table.GoName }}, error) {
+			{{ short $k.Table.GoName }} := {{ $k.Table.GoName }}{
+			{{- if $k.Table.PrimaryKeys }}
+				_exists: true,
+			{{ end -}}
+			}
+			err := s.Scan({{ names (print "&" (short $k.Table.GoName) ".") $k.Table.Fields }})
+			return &{{ short $k.Table.GoName }}, err
+		},
+	})
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	// the last record is the key for the next page
+	last, _ := page.Last()
+	return page.Items, last, nil
+}
+{{- end }}
 {{ end }}
 
 {{ define "index" }}
@@ -120,22 +180,28 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 //
 // Generated from index '{{ $i.SQLName }}'.
 {{ func_context $i }} {
+{{- if $i.Table.Tenant }}
+	// scope to the context's tenant
+	tenant, err := {{ tenant_from }}
+	if err != nil {
+		return nil, logerror(err)
+	}
+{{- end }}
 	// query
 	{{ sqlstr "index" $i }}
 	// run
-	logf(sqlstr, {{ params $i.Fields false }})
 {{- if $i.IsUnique }}
 	{{ short $i.Table }} := {{ $i.Table.GoName }}{
 	{{- if $i.Table.PrimaryKeys }}
 		_exists: true,
 	{{ end -}}
 	}
-	if err := {{ db "QueryRow"  $i }}.Scan({{ names (print "&" (short $i.Table) ".") $i.Table }}); err != nil {
+	if err := {{ if $i.Table.Tenant }}{{ db_select "Row" $i "tenant" }}{{ else }}{{ db_select "Row" $i }}{{ end }}.Scan({{ names (print "&" (short $i.Table) ".") $i.Table }}); err != nil {
 		return nil, logerror(err)
 	}
 	return &{{ short $i.Table }}, nil
 {{- else }}
-	rows, err := {{ db "Query" $i }}
+	rows, err := {{ if $i.Table.Tenant }}{{ db_select "Rows" $i "tenant" }}{{ else }}{{ db_select "Rows" $i }}{{ end }}
 	if err != nil {
 		return nil, logerror(err)
 	}
@@ -189,11 +255,11 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 {{- range $p.Returns }}
 	var {{ check_name .GoName }} {{ type .Type }}
 {{- end }}
-	logf(sqlstr, {{ params $p.Params false }})
 {{- if and (driver "sqlserver" "oracle") (eq $p.Type "procedure")}}
+	logf(sqlstr, {{ params $p.Params false }})
 	if _, err := {{ db_named "Exec" $p }}; err != nil {
 {{- else }}
-	if err := {{ db "QueryRow" $p }}.Scan({{ names "&" $p.Returns }}); err != nil {
+	if err := {{ db_select "Row" $p }}.Scan({{ names "&" $p.Returns }}); err != nil {
 {{- end }}
 		return {{ zero $p.Returns }}, logerror(err)
 	}
@@ -247,7 +313,11 @@ func ({{ short $t }} *{{ $t.GoName }}) Exists() bool {
 // Deleted returns true when the [{{ $t.GoName }}] has been marked for deletion
 // from the database.
 func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
+{{- if $t.SoftDelete }}
+	return {{ short $t }}._deleted || {{ short $t }}.{{ $t.SoftDelete.GoName }}.Valid
+{{- else }}
 	return {{ short $t }}._deleted
+{{- end }}
 }
 
 // {{ func_name_context "Insert" }} inserts the [{{ $t.GoName }}] to the database.
@@ -258,6 +328,12 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	case {{ short $t }}._deleted: // deleted
 		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
 	}
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(&ErrInsertFailed{err})
+	}
+{{- end }}
 {{ if $t.Manual -}}
 	// insert (manual)
 	{{ sqlstr "insert_manual" $t }}
@@ -324,6 +400,104 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 }
 {{- end }}
 
+// Insert{{ $t.GoName }}Batch inserts the [{{ $t.GoName }}] rows to the database using multi-row INSERT
+// statements, split into chunks that fit within the driver's placeholder limit.
+{{- if not $t.Manual }}
+// The primary keys generated by the database are set on the rows.
+{{- if driver "mysql" }}
+//
+// The primary keys are computed from the id of the first row inserted by each statement,
+// which requires a statement to allocate consecutive ids: the server must not run with
+// innodb_autoinc_lock_mode = 2 (interleaved) and auto_increment_increment must be 1.
+{{- end }}
+{{- end }}
+//
+// Each chunk is a separate statement: use a transaction to insert the rows atomically.
+func Insert{{ $t.GoName }}Batch(ctx context.Context, db DB, {{ short $t }}s []*{{ $t.GoName }}) error {
+{{- if driver "mysql" "postgres" "sqlite3" }}
+	for _, {{ short $t }} := range {{ short $t }}s {
+		switch {
+		case {{ short $t }}._exists: // already exists
+			return logerror(&ErrInsertFailed{ErrAlreadyExists})
+		case {{ short $t }}._deleted: // deleted
+			return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+		}
+{{- if $t.Tenant }}
+		// scope to the context's tenant
+		if err := {{ scope_tenant $t }}; err != nil {
+			return logerror(&ErrInsertFailed{err})
+		}
+{{- end }}
+	}
+{{- $fields := batch_fields $t $t.Manual }}
+	// insert{{ if not $t.Manual }} (primary key generated and returned by database){{ end }}
+	{{ sqlstr "insert_batch" $t }}
+	for len({{ short $t }}s) != 0 {
+		chunk := {{ short $t }}s[:min(len({{ short $t }}s), batchRows({{ len $fields }}))]
+		{{ short $t }}s = {{ short $t }}s[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*{{ len $fields }})
+		for _, {{ short $t }} := range chunk {
+			args = append(args, {{ names (print (short $t) ".") $fields }})
+		}
+		query := sqlstr + batchValues(len(chunk), {{ len $fields }})
+		// run
+		logf(query, args...)
+{{- if $t.Manual }}
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return logerror(err)
+		}
+{{- else if driver "postgres" }}
+		rows, err := db.QueryContext(ctx, query+` RETURNING {{ (index $t.PrimaryKeys 0).SQLName }}`, args...)
+		if err != nil {
+			return logerror(err)
+		}
+		// set primary keys, returned in insertion order
+		for i := 0; rows.Next() && i < len(chunk); i++ {
+			if err := rows.Scan(&chunk[i].{{ (index $t.PrimaryKeys 0).GoName }}); err != nil {
+				rows.Close()
+				return logerror(err)
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return logerror(err)
+		}
+{{- else }}
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return logerror(err)
+		}
+		// retrieve id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return logerror(err)
+		}
+{{- if driver "sqlite3" }}
+		// the id is the one of the last row
+		id -= int64(len(chunk) - 1)
+{{- end }}
+		// set primary keys, allocated consecutively for a multi-row insert
+		for i, {{ short $t }} := range chunk {
+			{{ short $t }}.{{ (index $t.PrimaryKeys 0).GoName }} = {{ (index $t.PrimaryKeys 0).Type }}(id + int64(i))
+		}
+{{- end }}
+		// set exists
+		for _, {{ short $t }} := range chunk {
+			{{ short $t }}._exists = true
+		}
+	}
+	return nil
+{{- else }}
+	// multi-row inserts do not return the generated keys, insert row by row
+	for _, {{ short $t }} := range {{ short $t }}s {
+		if err := {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}ctx, {{ end }}db); err != nil {
+			return err
+		}
+	}
+	return nil
+{{- end }}
+}
+
 
 {{ if eq (len $t.Fields) (len $t.PrimaryKeys) -}}
 // ------ NOTE: Update statements omitted due to lack of fields other than primary key ------
@@ -333,18 +507,42 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	switch {
 	case !{{ short $t }}._exists: // doesn't exist
 		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
-	case {{ short $t }}._deleted: // deleted
+	case {{ short $t }}.Deleted(): // deleted
 		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
 	}
-	// update with {{ if driver "postgres" }}composite {{ end }}primary key
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(&ErrUpdateFailed{err})
+	}
+{{- end }}
+	// update with {{ if driver "postgres" }}composite {{ end }}primary key{{ if $t.UpdatedAt }}, the database sets {{ $t.UpdatedAt.SQLName }}{{ end }}
 	{{ sqlstr "update" $t }}
 	// run
 	{{ logf_update $t }}
 	if _, err := {{ db_update "Exec" $t }}; err != nil {
 		return logerror(err)
 	}
+{{- if $t.UpdatedAt }}
+	return {{ short $t }}.touch(ctx, db)
+{{- else }}
+	return nil
+{{- end }}
+}
+{{- if $t.UpdatedAt }}
+
+// touch reads the {{ $t.UpdatedAt.SQLName }} set by the database on the last change of the [{{ $t.GoName }}],
+// which reports the change to [{{ $t.GoName }}ChangesSince].
+func ({{ short $t }} *{{ $t.GoName }}) touch(ctx context.Context, db DB) error {
+	// query
+	{{ sqlstr "touch" $t }}
+	// run
+	if err := {{ db_select "Row" (names (print (short $t) ".") (scope_keys $t)) }}.Scan(&{{ short $t }}.{{ $t.UpdatedAt.GoName }}); err != nil {
+		return logerror(err)
+	}
 	return nil
 }
+{{- end }}
 
 {{ if context_both -}}
 // Update updates a [{{ $t.GoName }}] in the database.
@@ -377,6 +575,12 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	case {{ short $t }}._deleted: // deleted
 		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
 	}
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(&ErrUpsertFailed{err})
+	}
+{{- end }}
 	// upsert
 	{{ sqlstr "upsert" $t }}
 	// run
@@ -394,18 +598,161 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 {{ recv $t "Upsert" }} {
 	return {{ short $t }}.UpsertContext(context.Background(), db)
 }
-{{- end -}}
 {{- end }}
 
+// Upsert{{ $t.GoName }}Batch performs an upsert for the [{{ $t.GoName }}] rows using multi-row statements,
+// split into chunks that fit within the driver's placeholder limit.
+//
+// Each chunk is a separate statement: use a transaction to upsert the rows atomically.
+func Upsert{{ $t.GoName }}Batch(ctx context.Context, db DB, {{ short $t }}s []*{{ $t.GoName }}) error {
+{{- if driver "mysql" "postgres" "sqlite3" }}
+	for _, {{ short $t }} := range {{ short $t }}s {
+		switch {
+		case {{ short $t }}._deleted: // deleted
+			return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+		}
+{{- if $t.Tenant }}
+		// scope to the context's tenant
+		if err := {{ scope_tenant $t }}; err != nil {
+			return logerror(&ErrUpsertFailed{err})
+		}
+{{- end }}
+	}
+	// upsert
+	{{ sqlstr "upsert_batch" $t }}
+	{{ sqlstr "upsert_conflict" $t }}
+	for len({{ short $t }}s) != 0 {
+		chunk := {{ short $t }}s[:min(len({{ short $t }}s), batchRows({{ len $t.Fields }}))]
+		{{ short $t }}s = {{ short $t }}s[len(chunk):]
+		// build values
+		args := make([]interface{}, 0, len(chunk)*{{ len $t.Fields }})
+		for _, {{ short $t }} := range chunk {
+			args = append(args, {{ names (print (short $t) ".") $t }})
+		}
+		query := sqlstr + batchValues(len(chunk), {{ len $t.Fields }}) + conflict
+		// run
+		logf(query, args...)
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return logerror(err)
+		}
+		// set exists
+		for _, {{ short $t }} := range chunk {
+			{{ short $t }}._exists = true
+		}
+	}
+	return nil
+{{- else }}
+	// upsert row by row
+	for _, {{ short $t }} := range {{ short $t }}s {
+		if err := {{ short $t }}.{{ func_name_context "Upsert" }}({{ if context }}ctx, {{ end }}db); err != nil {
+			return err
+		}
+	}
+	return nil
+{{- end }}
+}
+{{- end }}
+
+{{ if $t.SoftDelete -}}
+// {{ func_name_context "Delete" }} soft deletes the [{{ $t.GoName }}] from the database by setting
+// its {{ $t.SoftDelete.SQLName }} column. Use [{{ $t.GoName }}.{{ func_name_context "HardDelete" }}] to remove the row.
+{{ recv_context $t "Delete" }} {
+	switch {
+	case !{{ short $t }}._exists: // doesn't exist
+		return nil
+	case {{ short $t }}.Deleted(): // deleted
+		return nil
+	}
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(err)
+	}
+{{- end }}
+	// soft delete with primary key
+	{{ sqlstr "soft_delete" $t }}
+	// run
+	{{ param $t.SoftDelete false }} := sql.NullTime{Time: time.Now(), Valid: true}
+	logf(sqlstr, {{ param $t.SoftDelete false }}, {{ names (print (short $t) ".") (scope_keys $t) }})
+	if _, err := {{ db "Exec" (param $t.SoftDelete false) (names (print (short $t) ".") (scope_keys $t)) }}; err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	{{ short $t }}.{{ $t.SoftDelete.GoName }} = {{ param $t.SoftDelete false }}
+{{- if $t.UpdatedAt }}
+	return {{ short $t }}.touch(ctx, db)
+{{- else }}
+	return nil
+{{- end }}
+}
+
+{{ if context_both -}}
+// Delete soft deletes the [{{ $t.GoName }}] from the database.
+{{ recv $t "Delete" }} {
+	return {{ short $t }}.DeleteContext(context.Background(), db)
+}
+{{- end }}
+
+// {{ func_name_context "Restore" }} restores a soft deleted [{{ $t.GoName }}] by clearing its
+// {{ $t.SoftDelete.SQLName }} column.
+{{ recv_context $t "Restore" }} {
+	switch {
+	case !{{ short $t }}._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case {{ short $t }}._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	case !{{ short $t }}.{{ $t.SoftDelete.GoName }}.Valid: // not soft deleted
+		return nil
+	}
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(&ErrUpdateFailed{err})
+	}
+{{- end }}
+	// restore with primary key
+	{{ sqlstr "soft_delete" $t }}
+	// run
+	logf(sqlstr, nil, {{ names (print (short $t) ".") (scope_keys $t) }})
+	if _, err := {{ db "Exec" "nil" (names (print (short $t) ".") (scope_keys $t)) }}; err != nil {
+		return logerror(err)
+	}
+	// set restored
+	{{ short $t }}.{{ $t.SoftDelete.GoName }} = sql.NullTime{}
+{{- if $t.UpdatedAt }}
+	return {{ short $t }}.touch(ctx, db)
+{{- else }}
+	return nil
+{{- end }}
+}
+
+{{ if context_both -}}
+// Restore restores a soft deleted [{{ $t.GoName }}].
+{{ recv $t "Restore" }} {
+	return {{ short $t }}.RestoreContext(context.Background(), db)
+}
+{{- end }}
+
+// {{ func_name_context "HardDelete" }} permanently deletes the [{{ $t.GoName }}] from the database,
+// whether or not it has been soft deleted.
+{{ recv_context $t "HardDelete" }} {
+{{- else -}}
 // {{ func_name_context "Delete" }} deletes the [{{ $t.GoName }}] from the database.
 {{ recv_context $t "Delete" }} {
+{{- end }}
 	switch {
 	case !{{ short $t }}._exists: // doesn't exist
 		return nil
 	case {{ short $t }}._deleted: // deleted
 		return nil
 	}
-{{ if eq (len $t.PrimaryKeys) 1 -}}
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	if err := {{ scope_tenant $t }}; err != nil {
+		return logerror(err)
+	}
+{{- end }}
+{{ if eq (len (scope_keys $t)) 1 -}}
 	// delete with single primary key
 	{{ sqlstr "delete" $t }}
 	// run
@@ -418,7 +765,7 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	{{ sqlstr "delete" $t }}
 	// run
 	{{ logf_pkeys $t }}
-	if _, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) }}; err != nil {
+	if _, err := {{ db "Exec" (names (print (short $t) ".") (scope_keys $t)) }}; err != nil {
 		return logerror(err)
 	}
 {{- end }}
@@ -428,10 +775,17 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 }
 
 {{ if context_both -}}
+{{- if $t.SoftDelete }}
+// HardDelete permanently deletes the [{{ $t.GoName }}] from the database.
+{{ recv $t "HardDelete" }} {
+	return {{ short $t }}.HardDeleteContext(context.Background(), db)
+}
+{{- else }}
 // Delete deletes the [{{ $t.GoName }}] from the database.
-{{ recv $t "Delete" }} {K
+{{ recv $t "Delete" }} {
 	return {{ short $t }}.DeleteContext(context.Background(), db)
 }
+{{- end }}
 {{- end -}}
 {{- end }}
 
@@ -443,95 +797,145 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 //
 // If `order` is `ASC`, it retrieves records where the value of `column` is greater than `key`.
 // If `order` is `DESC`, it retrieves records where the value of `column` is less than `key`.
+// A nil `key` retrieves the first page.
+{{- if eq (len $t.PrimaryKeys) 1 }}
+// Records with equal values of `column` are ordered by `{{ (index $t.PrimaryKeys 0).SQLName }}`: a `key` made by
+// [paginator.AfterRow] from the values of `column` and `{{ (index $t.PrimaryKeys 0).SQLName }}` of the last record
+// resumes after it without skipping the records equal to it.
+{{- end }}
 //
 // Filters are dynamically provided via a `filters` map, where keys are column names and values are either single values or slices for `IN` clauses.
-func {{ $t.GoName }}KeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}) ([]*{{ $t.GoName }}, *{{ $t.GoName }}, error) {
-    if order != "ASC" && order != "DESC" {
-        return nil, nil, fmt.Errorf("invalid order: %s", order)
-    }
-
-    // Start building the query
-    query := fmt.Sprintf(
-        `SELECT * FROM {{ $t.SQLName }} 
-         WHERE %s %s ?`, 
-        column, condition(order),  // Ensure this is returning a valid operator
-    )
-
-    // Arguments for the query
-    args := []interface{}{key}
-
-    // Dynamically add filters from the `filters` map to the query
-    for field, value := range filters {
-        switch v := value.(type) {
-        case []int:
-            if len(v) > 0 {
-                placeholders := make([]string, len(v))
-                for i := range v {
-                    placeholders[i] = "?"
-                    args = append(args, v[i])
-                }
-                query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
-            }
-        case []string:
-            if len(v) > 0 {
-                placeholders := make([]string, len(v))
-                for i := range v {
-                    placeholders[i] = "?"
-                    args = append(args, v[i])
-                }
-                query += fmt.Sprintf(" AND %s IN (%s)", field, strings.Join(placeholders, ", "))
-            }
-        default:
-            query += fmt.Sprintf(" AND %s = ?", field)
-            args = append(args, value)
-        }
-    }
-
-    // Finalize the query with the order and limit
-    query += fmt.Sprintf(" ORDER BY %s %s LIMIT ?", column, order)
-    args = append(args, limit)
-
-    // Log the final query for debugging purposes
-    log.Printf("Executing query: %s with args: %v", query, args)
-
-    // Execute the query
-    rows, err := db.QueryContext(ctx, query, args...)
-    if err != nil {
-        return nil, nil, logerror(err)
-    }
-    defer rows.Close()
-
-    var results []*{{ $t.GoName }}
-    var lastItem *{{ $t.GoName }} // Variable to store the last item
-
-    for rows.Next() {
-        {{ short $t.GoName }} := {{ $t.GoName }}{
-        {{- if $t.PrimaryKeys }}
-            _exists: true,
-        {{ end -}}
-        }
-        if err := rows.Scan(
-            {{ range $t.Fields -}}
-            &{{ short $t.GoName }}.{{ .GoName }},
-            {{- end }}
-        ); err != nil {
-            return nil, nil, logerror(err)
-        }
-        results = append(results, &{{ short $t.GoName }})
-    }
-
-    // Check for errors during row iteration.
-    if err := rows.Err(); err != nil {
-        return nil, nil, logerror(err)
-    }
-
-    // If we have results, set the lastItem to the last element in results.
-    if len(results) > 0 {
-        lastItem = results[len(results)-1]
-    }
-
-    return results, lastItem, nil
+// Typed conditions such as time ranges are provided via `where`.
+// Invalid columns, orders, limits and filters are reported as a [paginator.FieldError].
+func {{ $t.GoName }}KeysetPage(ctx context.Context, db DB, column string, key interface{}, limit int, order string, filters map[string]interface{}, where ...paginator.Condition) ([]*{{ $t.GoName }}, *{{ $t.GoName }}, error) {
+	q := paginator.Query{
+		From:    "{{ $t.SQLName }}",
+		Columns: []string{ {{- range $i, $f := $t.Fields }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+{{- if $t.SoftDelete }}
+		// Exclude soft deleted rows according to the context's scope
+		Where:   append(deletedConditions(ctx, "{{ $t.SoftDelete.SQLName }}"), where...),
+{{- else }}
+		Where:   where,
+{{- end }}
+		Sort:    paginator.SortKey{Column: column, Order: paginator.Order(order)},
+{{- if eq (len $t.PrimaryKeys) 1 }}
+		Tiebreak: "{{ (index $t.PrimaryKeys 0).SQLName }}",
+{{- end }}
+		Filters: filters,
+		Limit:   limit,
+	}
+{{- if $t.Tenant }}
+	// Scope the query to the context's tenant
+	tenant, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	q.Where = append(q.Where, paginator.Eq("{{ $t.Tenant.SQLName }}", tenant))
+{{- end }}
+	page, err := paginator.Fetch(ctx, logQueryer{db}, dialect, q, paginator.After(key), paginator.Mapper[*{{ $t.GoName }}]{
+		Scan: func(s paginator.Scanner) (*{{ $t.GoName }}, error) {
+			{{ short $t.GoName }} := {{ $t.GoName }}{
+			{{- if $t.PrimaryKeys }}
+				_exists: true,
+			{{ end -}}
+			}
+			err := s.Scan({{ names (print "&" (short $t.GoName) ".") $t.Fields }})
+			return &{{ short $t.GoName }}, err
+		},
+	})
+	if err != nil {
+		return nil, nil, logerror(err)
+	}
+	// the last record is the key for the next page
+	last, _ := page.Last()
+	return page.Items, last, nil
+}
+
+// {{ $t.GoName }}Collection describes the '{{ schema $t.SQLName }}' table paged by [{{ $t.GoName }}KeysetPage].
+//
+// Generated from the columns and indexes of '{{ $t.SQLName }}'.
+var {{ $t.GoName }}Collection = &Collection{
+	Name: "{{ $t.SQLName }}",
+	Fields: []CollectionField{
+{{- range $t.Fields }}
+		{Name: "{{ .SQLName }}", Type: "{{ .SQLType }}", GoType: "{{ .Type }}", Nullable: {{ .Nullable }}, Operators: []string{ {{- range $i, $op := filter_ops . }}{{ if $i }}, {{ end }}"{{ $op }}"{{ end -}} }},
+{{- end }}
+	},
+	SortKeys: []string{ {{- range $i, $f := $t.SortKeys }}{{ if $i }}, {{ end }}"{{ $f.SQLName }}"{{ end -}} },
+}
+
+func init() {
+	registerCollection({{ $t.GoName }}Collection)
 }
+{{- if $t.UpdatedAt }}
+{{- $pk := index $t.PrimaryKeys 0 }}
+
+// {{ $t.GoName }}Watermark is a position in the [{{ $t.GoName }}] change feed: the {{ $t.UpdatedAt.SQLName }} and
+// {{ $pk.SQLName }} of the last change read. The zero value starts from the first change.
+type {{ $t.GoName }}Watermark struct {
+	{{ $t.UpdatedAt.GoName }} {{ $t.UpdatedAt.Type }} `json:"{{ $t.UpdatedAt.SQLName }}"`
+	{{ $pk.GoName }} {{ $pk.Type }} `json:"{{ $pk.SQLName }}"`
+}
+
+// {{ $t.GoName }}ChangesSince retrieves up to `limit` [{{ $t.GoName }}] records changed after the watermark,
+// ordered by ({{ $t.UpdatedAt.SQLName }}, {{ $pk.SQLName }}), with the watermark to resume from. The watermark
+// is returned unchanged when there are no new changes.
+//
+// The {{ $t.UpdatedAt.SQLName }} of a change is set by the database (ON UPDATE CURRENT_TIMESTAMP(6)) when
+// its statement runs, not when its transaction commits: a change committed after a later
+// change has been read falls behind the watermark and is skipped. Consumers that need every
+// change should resume from a watermark moved back by the longest transaction, and skip the
+// records read again.
+//
+{{- if $t.SoftDelete }}
+// Soft deleted records are returned whatever the context's [DeletedScope], so that
+// deletions are reported: check [{{ $t.GoName }}.Deleted]. Rows removed with
+// [{{ $t.GoName }}.{{ func_name_context "HardDelete" }}] are not reported.
+{{- else }}
+// Deleted rows are not reported; add a nullable `deleted_at` column to the table to
+// report deletions as soft deleted records.
+{{- end }}
+func {{ $t.GoName }}ChangesSince(ctx context.Context, db DB, watermark {{ $t.GoName }}Watermark, limit int) ([]*{{ $t.GoName }}, {{ $t.GoName }}Watermark, error) {
+{{- if $t.Tenant }}
+	// scope to the context's tenant
+	tenant, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, watermark, logerror(err)
+	}
+{{- end }}
+	// query
+	{{ sqlstr "changes" $t }}
+	// run
+{{- $args := print "watermark." $t.UpdatedAt.GoName ", watermark." $t.UpdatedAt.GoName ", watermark." $pk.GoName }}
+{{- if $t.Tenant }}{{ $args = print $args ", tenant" }}{{ end }}
+	rows, err := logQueryer{db}.QueryContext(ctx, sqlstr, {{ $args }}, limit)
+	if err != nil {
+		return nil, watermark, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*{{ $t.GoName }}
+	for rows.Next() {
+		{{ short $t }} := {{ $t.GoName }}{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan({{ names (print "&" (short $t) ".") $t }}); err != nil {
+			return nil, watermark, logerror(err)
+		}
+		res = append(res, &{{ short $t }})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, watermark, logerror(err)
+	}
+	// resume after the last change
+	if len(res) > 0 {
+		last := res[len(res)-1]
+		watermark = {{ $t.GoName }}Watermark{ {{- $t.UpdatedAt.GoName }}: last.{{ $t.UpdatedAt.GoName }}, {{ $pk.GoName }}: last.{{ $pk.GoName }}}
+	}
+	return res, watermark, nil
+}
+{{- end }}
 
 {{ end }}
 // Define other functions here that you want globally